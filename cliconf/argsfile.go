@@ -0,0 +1,193 @@
+package cliconf
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// ExpandArgsFiles replaces each arg starting with @ with the args read from
+// the named file, e.g. `@deploy.args`, for arg lists which are too long for
+// the command line.
+//
+// Only args in argument position are expanded, never the value of a flag,
+// e.g. `--password @/run/secret` is left for the field to handle, as with the
+// fromfile tag, and nothing after a `--` terminator is expanded. Flags take
+// the following arg as their value unless given as `--name=value`, or named
+// in booleans, as when parsing.
+//
+// Files are split into args on whitespace, including newlines. Quoting follows
+// the shell: single quotes keep everything verbatim, double quotes allow \"
+// and \\ escapes, and a backslash outside of quotes escapes the next
+// character. A # at the start of an arg begins a comment to the end of the
+// line.
+//
+// Args in a file may themselves reference files with @, relative paths are
+// resolved from the working directory. A file which includes itself, directly
+// or indirectly, is an error.
+func ExpandArgsFiles(args []string, booleans ...string) ([]string, error) {
+	booleanSet := make(map[string]struct{}, len(booleans))
+	for _, name := range booleans {
+		booleanSet[name] = struct{}{}
+	}
+	expander := &argsExpander{booleans: booleanSet}
+	return expander.expand(args, nil)
+}
+
+// argsExpander tracks the position in the args across files, as a file can
+// end with a flag whose value follows the file, or contain the terminator
+type argsExpander struct {
+	booleans map[string]struct{}
+	resolve  flagResolver
+
+	// valueNext is set after a flag which takes the next arg as its value
+	valueNext bool
+
+	// plainArgs is set from the first positional arg, after which nothing
+	// is a flag
+	plainArgs bool
+
+	terminated bool
+}
+
+func (ae *argsExpander) expand(args []string, stack []string) ([]string, error) {
+	out := make([]string, 0, len(args))
+	for _, arg := range args {
+		if !ae.argPosition(arg) {
+			out = append(out, arg)
+			continue
+		}
+
+		filename, ok := strings.CutPrefix(arg, "@")
+		if !ok || filename == "" {
+			ae.plainArgs = true
+			out = append(out, arg)
+			continue
+		}
+
+		absName, err := filepath.Abs(filename)
+		if err != nil {
+			return nil, fmt.Errorf("args file %s: %w", filename, err)
+		}
+		if slices.Contains(stack, absName) {
+			return nil, fmt.Errorf("args file %s includes itself", filename)
+		}
+
+		fileData, err := os.ReadFile(filename)
+		if err != nil {
+			return nil, fmt.Errorf("args file %s: %w", filename, err)
+		}
+
+		fileArgs, err := splitArgs(string(fileData))
+		if err != nil {
+			return nil, fmt.Errorf("args file %s: %w", filename, err)
+		}
+
+		fileArgs, err = ae.expand(fileArgs, append(stack, absName))
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, fileArgs...)
+	}
+	return out, nil
+}
+
+// argPosition is true when the arg is neither a flag, the value of a flag,
+// nor after the terminator, updating the position for the next arg.
+func (ae *argsExpander) argPosition(arg string) bool {
+	switch {
+	case ae.terminated:
+		return false
+	case ae.valueNext:
+		ae.valueNext = false
+		return false
+	case arg == "--":
+		ae.terminated = true
+		return false
+	case ae.plainArgs:
+		return true
+	case !strings.HasPrefix(arg, "-"):
+		return true
+	}
+
+	name, _, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+	if hasValue {
+		return false
+	}
+	if ae.resolve != nil {
+		if resolved, err := ae.resolve(name); err == nil {
+			name = resolved
+		}
+	}
+	if _, ok := ae.booleans[name]; !ok {
+		ae.valueNext = true
+	}
+	return false
+}
+
+var errUnterminatedQuote = errors.New("unterminated quote")
+
+// splitArgs splits the contents of an args file, see ExpandArgsFiles
+func splitArgs(data string) ([]string, error) {
+	args := make([]string, 0)
+	current := &strings.Builder{}
+	inArg := false
+
+	for idx := 0; idx < len(data); idx++ {
+		char := data[idx]
+		switch {
+		case char == ' ' || char == '\t' || char == '\n' || char == '\r':
+			if inArg {
+				args = append(args, current.String())
+				current.Reset()
+				inArg = false
+			}
+
+		case char == '#' && !inArg:
+			end := strings.IndexByte(data[idx:], '\n')
+			if end < 0 {
+				return args, nil
+			}
+			idx += end
+
+		case char == '\'':
+			end := strings.IndexByte(data[idx+1:], '\'')
+			if end < 0 {
+				return nil, errUnterminatedQuote
+			}
+			current.WriteString(data[idx+1 : idx+1+end])
+			idx += end + 1
+			inArg = true
+
+		case char == '"':
+			idx++
+			for ; idx < len(data) && data[idx] != '"'; idx++ {
+				if data[idx] == '\\' && idx+1 < len(data) && (data[idx+1] == '"' || data[idx+1] == '\\') {
+					idx++
+				}
+				current.WriteByte(data[idx])
+			}
+			if idx >= len(data) {
+				return nil, errUnterminatedQuote
+			}
+			inArg = true
+
+		case char == '\\' && idx+1 < len(data):
+			idx++
+			current.WriteByte(data[idx])
+			inArg = true
+
+		default:
+			current.WriteByte(char)
+			inArg = true
+		}
+	}
+
+	if inArg {
+		args = append(args, current.String())
+	}
+	return args, nil
+}
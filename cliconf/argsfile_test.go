@@ -0,0 +1,140 @@
+package cliconf
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestExpandArgsFiles(t *testing.T) {
+
+	dir := t.TempDir()
+	writeFile := func(name, data string) string {
+		filename := filepath.Join(dir, name)
+		if err := os.WriteFile(filename, []byte(data), 0600); err != nil {
+			t.Fatal(err)
+		}
+		return filename
+	}
+
+	nested := writeFile("nested.args", "--nested\nvalue\n")
+	simple := writeFile("simple.args", `# deploy args
+--name "two words" --path 'a "quoted" b'
+--escaped a\ b "c\"d" @`+nested+`
+`)
+
+	got, err := ExpandArgsFiles([]string{"cmd", "@" + simple, "after", "@"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	want := []string{
+		"cmd",
+		"--name", "two words",
+		"--path", `a "quoted" b`,
+		"--escaped", "a b", `c"d`,
+		"--nested", "value",
+		"after", "@",
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+
+	t.Run("cycle", func(t *testing.T) {
+		loopA := filepath.Join(dir, "a.args")
+		loopB := writeFile("b.args", "@"+loopA)
+		writeFile("a.args", "@"+loopB)
+
+		_, err := ExpandArgsFiles([]string{"@" + loopA})
+		if err == nil || !strings.Contains(err.Error(), "includes itself") {
+			t.Errorf("Expected cycle error, got %v", err)
+		}
+	})
+
+	t.Run("unterminated quote", func(t *testing.T) {
+		bad := writeFile("bad.args", `--name "open`)
+		_, err := ExpandArgsFiles([]string{"@" + bad})
+		if err == nil || !strings.Contains(err.Error(), "unterminated quote") {
+			t.Errorf("Expected quote error, got %v", err)
+		}
+	})
+
+	t.Run("parse option", func(t *testing.T) {
+		type Config struct {
+			Name string `flag:"name"`
+			Path string `flag:"path"`
+		}
+		argsFile := writeFile("parse.args", "--name foo\n--path bar\n")
+
+		cfg := &Config{}
+		err := ParseCombined(reflect.ValueOf(cfg), []string{"@" + argsFile}, WithArgsFiles())
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if cfg.Name != "foo" || cfg.Path != "bar" {
+			t.Errorf("Expected foo and bar, got %+v", cfg)
+		}
+	})
+
+	t.Run("argument position only", func(t *testing.T) {
+		listed := writeFile("listed.args", "--verbose")
+		for _, tc := range []struct {
+			name     string
+			args     []string
+			booleans []string
+			want     []string
+		}{{
+			name: "flag value",
+			args: []string{"--password", "@" + listed},
+			want: []string{"--password", "@" + listed},
+		}, {
+			name: "flag value with equals",
+			args: []string{"--password=@" + listed},
+			want: []string{"--password=@" + listed},
+		}, {
+			name:     "after boolean",
+			args:     []string{"--debug", "@" + listed},
+			booleans: []string{"debug"},
+			want:     []string{"--debug", "--verbose"},
+		}, {
+			name: "after terminator",
+			args: []string{"--", "@" + listed},
+			want: []string{"--", "@" + listed},
+		}, {
+			name: "positional",
+			args: []string{"cmd", "@" + listed},
+			want: []string{"cmd", "--verbose"},
+		}} {
+			t.Run(tc.name, func(t *testing.T) {
+				got, err := ExpandArgsFiles(tc.args, tc.booleans...)
+				if err != nil {
+					t.Fatalf("Expected no error, got %v", err)
+				}
+				if !slices.Equal(got, tc.want) {
+					t.Errorf("Expected %q, got %q", tc.want, got)
+				}
+			})
+		}
+	})
+
+	t.Run("with fromfile", func(t *testing.T) {
+		type Config struct {
+			Name     string `flag:"name"`
+			Debug    bool   `flag:"debug"`
+			Password string `flag:"password" fromfile:"true"`
+		}
+		secretFile := writeFile("password", "correct horse\n")
+		argsFile := writeFile("secret.args", "--name foo\n--password @"+secretFile+"\n")
+
+		cfg := &Config{}
+		err := ParseCombined(reflect.ValueOf(cfg), []string{"--debug", "@" + argsFile}, WithArgsFiles())
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if cfg.Name != "foo" || !cfg.Debug || cfg.Password != "correct horse" {
+			t.Errorf("Expected foo, debug and the password from the file, got %+v", cfg)
+		}
+	})
+}
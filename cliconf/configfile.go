@@ -0,0 +1,124 @@
+package cliconf
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const configFileFlag = "config"
+
+// configFile holds the values of a YAML or JSON config file, loaded with
+// --config. Values from the file fill in any field not set by a flag, source
+// or env var, but take precedence over defaults.
+//
+// Fields are matched by walking nested objects along the Go field path, so
+// a nested struct maps to a nested object, with the last key being either the
+// flag name or the field name. Keys are case insensitive. Fields with a flag
+// name also match a top level key with that name. The file may also be a URI
+// for a registered ConfigFetcher.
+//
+// Values are converted back to strings and then parsed with SetFromString in
+// the same way as flags, rather than unmarshalled directly into the field,
+// so that custom types, validation and the other tags behave identically no
+// matter where the value came from. Lists are joined with commas, objects
+// (for struct fields) are re-encoded as JSON.
+type configFile map[string]interface{}
+
+func readConfigFile(filename string) (configFile, error) {
+	fileData, err := fetchConfig(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	// JSON is valid YAML, so one parser covers both
+	values := map[string]interface{}{}
+	if err := yaml.Unmarshal(fileData, &values); err != nil {
+		return nil, fmt.Errorf("parsing config file %s: %w", filename, err)
+	}
+	return configFile(values), nil
+}
+
+func (cf configFile) lookupField(tag *field) (*string, error) {
+	path := strings.Split(tag.fieldName, ".")
+
+	val, ok := cf.walk(path[:len(path)-1], path[len(path)-1], tag.flagName)
+	if !ok && len(path) > 1 && tag.flagName != "" {
+		val, ok = cf.walk(nil, tag.flagName, "")
+	}
+	if !ok {
+		return nil, nil
+	}
+
+	stringVal, err := configValueString(val)
+	if err != nil {
+		return nil, fmt.Errorf("config file value for %s: %w", tag.fieldName, err)
+	}
+	return &stringVal, nil
+}
+
+func (cf configFile) walk(parents []string, names ...string) (interface{}, bool) {
+	current := map[string]interface{}(cf)
+	for _, parent := range parents {
+		child, ok := lookupKey(current, parent)
+		if !ok {
+			return nil, false
+		}
+		childMap, ok := child.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		current = childMap
+	}
+
+	for _, name := range names {
+		if name == "" {
+			continue
+		}
+		if val, ok := lookupKey(current, name); ok {
+			return val, true
+		}
+	}
+	return nil, false
+}
+
+func lookupKey(values map[string]interface{}, key string) (interface{}, bool) {
+	if val, ok := values[key]; ok {
+		return val, true
+	}
+	for k, val := range values {
+		if strings.EqualFold(k, key) {
+			return val, true
+		}
+	}
+	return nil, false
+}
+
+func configValueString(val interface{}) (string, error) {
+	switch val := val.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return val, nil
+	case []interface{}:
+		parts := make([]string, 0, len(val))
+		for _, item := range val {
+			part, err := configValueString(item)
+			if err != nil {
+				return "", err
+			}
+			parts = append(parts, part)
+		}
+		return strings.Join(parts, ","), nil
+	case map[string]interface{}:
+		jsonVal, err := json.Marshal(val)
+		if err != nil {
+			return "", err
+		}
+		return string(jsonVal), nil
+	default:
+		return fmt.Sprint(val), nil
+	}
+}
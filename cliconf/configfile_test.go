@@ -0,0 +1,66 @@
+package cliconf
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestConfigFile(t *testing.T) {
+
+	type Database struct {
+		Host string `flag:"db-host"`
+		Port int    `flag:"db-port" default:"5432"`
+	}
+
+	type Config struct {
+		Name     string   `flag:"name" env:"NAME"`
+		Verbose  bool     `flag:"verbose"`
+		Tags     []string `flag:"tags" optional:"true"`
+		Level    string   `flag:"level" env:"LEVEL" default:"info"`
+		Database Database
+	}
+
+	configData := `
+name: from-file
+verbose: true
+tags:
+  - a
+  - b
+level: debug
+database:
+  db-host: db.local
+`
+	configFilename := filepath.Join(t.TempDir(), "app.yaml")
+	if err := os.WriteFile(configFilename, []byte(configData), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("LEVEL", "warn")
+
+	cfg := &Config{}
+	err := ParseCombined(reflect.ValueOf(cfg), []string{"--config", configFilename, "--name=from-flag"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if cfg.Name != "from-flag" {
+		t.Errorf("Name: Expected flag to win over config file, got %q", cfg.Name)
+	}
+	if cfg.Level != "warn" {
+		t.Errorf("Level: Expected env to win over config file, got %q", cfg.Level)
+	}
+	if !cfg.Verbose {
+		t.Errorf("Verbose: Expected true from config file")
+	}
+	if !reflect.DeepEqual(cfg.Tags, []string{"a", "b"}) {
+		t.Errorf("Tags: Expected [a b], got %v", cfg.Tags)
+	}
+	if cfg.Database.Host != "db.local" {
+		t.Errorf("Host: Expected nested value from config file, got %q", cfg.Database.Host)
+	}
+	if cfg.Database.Port != 5432 {
+		t.Errorf("Port: Expected default, got %d", cfg.Database.Port)
+	}
+}
@@ -0,0 +1,116 @@
+package cliconf
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Describe returns the value of each field of a parsed config, keyed by the
+// Go field name as in ParseResult, e.g. "Foo" or "Nested.Foo". Values are
+// formatted so they could be given back as a flag, e.g. lists are joined with
+// commas and structs are JSON. Secret fields are masked as ****, and nil
+// pointers are left out.
+//
+// Describe reads the values, not where they came from, so it is meant to be
+// called after parsing to show the effective config, e.g. when debugging
+// which of the flags, env vars and defaults won. The config is not modified:
+// nil embedded pointers are left nil, and their fields are left out.
+func Describe(rv reflect.Value) map[string]string {
+	described := map[string]string{}
+	if rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			return described
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return described
+	}
+
+	fields, err := readStructFields(rv)
+	if err != nil {
+		return described
+	}
+	for _, field := range fields {
+		if val, ok := describeValue(field); ok {
+			described[field.fieldName] = val
+		}
+	}
+	return described
+}
+
+func describeValue(field *field) (string, bool) {
+	fieldVal := field.fieldVal
+	for fieldVal.Kind() == reflect.Pointer {
+		if fieldVal.IsNil() {
+			return "", false
+		}
+		fieldVal = fieldVal.Elem()
+	}
+
+	if field.secret && !fieldVal.IsZero() {
+		return "****", true
+	}
+
+	if fieldVal.Kind() == reflect.Slice && fieldVal.Type().Elem().Kind() == reflect.Uint8 {
+		return describeBytes(field.encoding, fieldVal.Bytes()), true
+	}
+
+	return formatValue(fieldVal), true
+}
+
+func describeBytes(encoding string, data []byte) string {
+	switch encoding {
+	case "base64":
+		return base64.StdEncoding.EncodeToString(data)
+	case "base64url":
+		return base64.URLEncoding.EncodeToString(data)
+	case "hex":
+		return hex.EncodeToString(data)
+	}
+	return string(data)
+}
+
+func formatValue(val reflect.Value) string {
+	switch typed := val.Interface().(type) {
+	case time.Time:
+		return typed.Format(time.RFC3339)
+	case fmt.Stringer:
+		return typed.String()
+	}
+
+	switch val.Kind() {
+	case reflect.Slice:
+		items := make([]string, val.Len())
+		for idx := range items {
+			items[idx] = formatValue(val.Index(idx))
+		}
+		return strings.Join(items, ",")
+	case reflect.Map:
+		return describeMap(val)
+	case reflect.Struct:
+		encoded, err := json.Marshal(val.Interface())
+		if err != nil {
+			return fmt.Sprintf("%+v", val.Interface())
+		}
+		return string(encoded)
+	}
+	return fmt.Sprint(val.Interface())
+}
+
+// describeMap lists the entries of a map as key=value, sorted by key
+func describeMap(val reflect.Value) string {
+	items := make([]string, 0, val.Len())
+	iter := val.MapRange()
+	for iter.Next() {
+		items = append(items, formatValue(iter.Key())+"="+formatValue(iter.Value()))
+	}
+	sort.Strings(items)
+	return strings.Join(items, ",")
+}
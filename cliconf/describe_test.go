@@ -0,0 +1,89 @@
+package cliconf
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDescribe(t *testing.T) {
+
+	type Nested struct {
+		Host string `flag:"host" default:"localhost"`
+	}
+
+	type Config struct {
+		Name     string        `flag:"name" env:"DESCRIBE_TEST_NAME"`
+		Port     int           `flag:"port" default:"8080"`
+		Timeout  time.Duration `flag:"timeout" default:"5s"`
+		Tags     []string      `flag:"tags" default:"a,b"`
+		Key      []byte        `flag:"key" encoding:"hex" default:"beef"`
+		Password string        `flag:"password" secret:"true" default:"hunter2"`
+		Token    string        `flag:"token" secret:"true" optional:"true"`
+		Limit    *int          `flag:"limit" optional:"true"`
+		Nested   Nested
+	}
+
+	t.Setenv("DESCRIBE_TEST_NAME", "from-env")
+
+	cfg := &Config{}
+	if err := ParseCombined(reflect.ValueOf(cfg), []string{"--port=9090", "--host=example.com"}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	want := map[string]string{
+		"Name":        "from-env",
+		"Port":        "9090",
+		"Timeout":     "5s",
+		"Tags":        "a,b",
+		"Key":         "beef",
+		"Password":    "****",
+		"Token":       "",
+		"Nested.Host": "example.com",
+	}
+	got := Describe(reflect.ValueOf(cfg))
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+}
+
+func TestDescribeNilEmbed(t *testing.T) {
+
+	type Base struct {
+		Verbose bool `flag:"verbose"`
+	}
+
+	type Config struct {
+		*Base
+		Name string `flag:"name"`
+	}
+
+	want := map[string]string{
+		"Name": "svc",
+	}
+
+	t.Run("value", func(t *testing.T) {
+		got := Describe(reflect.ValueOf(Config{Name: "svc"}))
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("Expected %v, got %v", want, got)
+		}
+	})
+
+	t.Run("pointer", func(t *testing.T) {
+		cfg := &Config{Name: "svc"}
+		got := Describe(reflect.ValueOf(cfg))
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("Expected %v, got %v", want, got)
+		}
+		if cfg.Base != nil {
+			t.Errorf("Expected the embedded struct not to be allocated")
+		}
+	})
+
+	t.Run("set", func(t *testing.T) {
+		got := Describe(reflect.ValueOf(Config{Base: &Base{Verbose: true}, Name: "svc"}))
+		if got["Verbose"] != "true" {
+			t.Errorf("Expected Verbose=true, got %v", got)
+		}
+	})
+}
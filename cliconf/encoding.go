@@ -0,0 +1,41 @@
+package cliconf
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"reflect"
+)
+
+// byteEncodings are the supported values of the `encoding` tag, used to decode
+// string values into []byte fields. Without the tag the raw string bytes are
+// used.
+var byteEncodings = map[string]func(string) ([]byte, error){
+	"base64":    base64.StdEncoding.DecodeString,
+	"base64url": base64.URLEncoding.DecodeString,
+	"hex":       hex.DecodeString,
+}
+
+func checkEncodingTag(inputField reflect.StructField, encoding string) error {
+	if _, ok := byteEncodings[encoding]; !ok {
+		return fmt.Errorf("field %s: unknown encoding %q", inputField.Name, encoding)
+	}
+	fieldType := inputField.Type
+	if fieldType.Kind() == reflect.Pointer {
+		fieldType = fieldType.Elem()
+	}
+	if fieldType.Kind() != reflect.Slice || fieldType.Elem().Kind() != reflect.Uint8 {
+		return fmt.Errorf("field %s: encoding can only be used on []byte fields", inputField.Name)
+	}
+	return nil
+}
+
+func setEncodedBytes(field *field, stringValue string) error {
+	decoded, err := byteEncodings[field.encoding](stringValue)
+	if err != nil {
+		return fmt.Errorf("invalid %s: %w", field.encoding, err)
+	}
+
+	allocValue(field.fieldVal).SetBytes(decoded)
+	return nil
+}
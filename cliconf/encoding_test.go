@@ -0,0 +1,67 @@
+package cliconf
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestByteEncoding(t *testing.T) {
+
+	type Config struct {
+		Raw    []byte `flag:"raw" optional:"true"`
+		Std    []byte `flag:"std" optional:"true" encoding:"base64"`
+		URLStd []byte `flag:"url" optional:"true" encoding:"base64url"`
+	}
+
+	// 0xfb 0xff encodes differently in the two alphabets
+	cfg := &Config{}
+	err := ParseCombined(reflect.ValueOf(cfg), []string{"--raw=+/8=", "--std=+/8=", "--url=-_8="})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if string(cfg.Raw) != "+/8=" {
+		t.Errorf("Raw: Expected raw bytes, got %q", cfg.Raw)
+	}
+	if !bytes.Equal(cfg.Std, []byte{0xfb, 0xff}) {
+		t.Errorf("Std: Expected decoded bytes, got %v", cfg.Std)
+	}
+	if !bytes.Equal(cfg.URLStd, []byte{0xfb, 0xff}) {
+		t.Errorf("URL: Expected decoded bytes, got %v", cfg.URLStd)
+	}
+
+	err = ParseCombined(reflect.ValueOf(&Config{}), []string{"--std=-_8="})
+	paramErrors := ParamErrors{}
+	if !errors.As(err, &paramErrors) {
+		t.Fatalf("Expected ParamErrors, got %v", err)
+	}
+	if len(paramErrors) != 1 || paramErrors[0].FieldName != "Std" {
+		t.Errorf("Expected 1 error for Std, got %v", paramErrors)
+	}
+}
+
+func TestHexEncoding(t *testing.T) {
+
+	type Config struct {
+		Key *[]byte `flag:"key" encoding:"hex"`
+	}
+
+	cfg := &Config{}
+	if err := ParseCombined(reflect.ValueOf(cfg), []string{"--key=deadbeef"}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if cfg.Key == nil || !bytes.Equal(*cfg.Key, []byte{0xde, 0xad, 0xbe, 0xef}) {
+		t.Errorf("Expected decoded bytes, got %v", cfg.Key)
+	}
+
+	err := ParseCombined(reflect.ValueOf(&Config{}), []string{"--key=nothex"})
+	paramErrors := ParamErrors{}
+	if !errors.As(err, &paramErrors) {
+		t.Fatalf("Expected ParamErrors, got %v", err)
+	}
+	if len(paramErrors) != 1 || paramErrors[0].FieldName != "Key" {
+		t.Errorf("Expected 1 error for Key, got %v", paramErrors)
+	}
+}
@@ -1,16 +1,26 @@
 package cliconf
 
 import (
+	"errors"
+	"io/fs"
 	"os"
 	"strings"
 )
 
+// ReadEnvFile reads KEY=value lines from a file. Keys and values may also be
+// separated by whitespace, as in `KEY value`. Lines starting with # are
+// comments.
+//
+// Values may be quoted with ' or ", the quotes are removed and the value is
+// kept verbatim, so quoted values may contain #. Unquoted values end at an
+// inline comment, a # at the start of the value or following whitespace, so
+// `KEY=val # comment` is `val`, while `KEY="a # b"` is `a # b`.
 func ReadEnvFile(filename string) (map[string]string, error) {
 	if filename == "" {
 		return nil, nil
 	}
 
-	fileData, err := os.ReadFile(filename)
+	fileData, err := fetchConfig(filename)
 	if err != nil {
 		return nil, err
 	}
@@ -18,23 +28,49 @@ func ReadEnvFile(filename string) (map[string]string, error) {
 	lines := strings.Split(string(fileData), "\n")
 	envMap := make(map[string]string)
 	for _, line := range lines {
-		if strings.HasPrefix(line, "#") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
 			continue
 		}
 
-		parts := strings.SplitN(line, "=", 2)
-		if len(parts) != 2 {
-			continue
+		key, value, ok := strings.Cut(line, "=")
+		if !ok || strings.ContainsAny(strings.TrimSpace(key), " \t") {
+			// `KEY value`, split on the first space or tab
+			split := strings.IndexAny(line, " \t")
+			if split < 0 {
+				continue
+			}
+			key, value = line[:split], line[split+1:]
 		}
 
-		key := strings.TrimSpace(parts[0])
-		value := strings.TrimSpace(parts[1])
-		envMap[key] = value
+		key = strings.TrimSpace(key)
+		envMap[key] = parseEnvValue(strings.TrimSpace(value))
 	}
 
 	return envMap, nil
 }
 
+func parseEnvValue(value string) string {
+	if len(value) >= 2 {
+		quote := value[0]
+		if quote == '"' || quote == '\'' {
+			if end := strings.IndexByte(value[1:], quote); end >= 0 {
+				return value[1 : end+1]
+			}
+		}
+	}
+
+	if strings.HasPrefix(value, "#") {
+		return ""
+	}
+	for idx := 1; idx < len(value); idx++ {
+		if value[idx] == '#' && (value[idx-1] == ' ' || value[idx-1] == '\t') {
+			return strings.TrimSpace(value[:idx])
+		}
+	}
+	return value
+}
+
 func LoadEnvFile(filename string) error {
 	env, err := ReadEnvFile(filename)
 	if err != nil {
@@ -47,3 +83,12 @@ func LoadEnvFile(filename string) error {
 	}
 	return nil
 }
+
+// loadOptionalEnvFile loads the env file if it exists
+func loadOptionalEnvFile(filename string) error {
+	err := LoadEnvFile(filename)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	return err
+}
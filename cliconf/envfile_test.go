@@ -0,0 +1,125 @@
+package cliconf
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestReadEnvFile(t *testing.T) {
+
+	envData := `# comment
+PLAIN=value
+SPACED = value with spaces
+NOEQUALS value
+TABBED	value
+TABEQUALS	a=b
+SPACEEQUALS a=b
+COMMENTED=val # comment
+HASH=a#b
+DOUBLE="a # b"
+SINGLE='c # d' # comment
+EMPTY=
+  # indented comment
+`
+	envFilename := filepath.Join(t.TempDir(), ".env")
+	if err := os.WriteFile(envFilename, []byte(envData), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := ReadEnvFile(envFilename)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	want := map[string]string{
+		"PLAIN":       "value",
+		"SPACED":      "value with spaces",
+		"NOEQUALS":    "value",
+		"TABBED":      "value",
+		"TABEQUALS":   "a=b",
+		"SPACEEQUALS": "a=b",
+		"COMMENTED":   "val",
+		"HASH":        "a#b",
+		"DOUBLE":      "a # b",
+		"SINGLE":      "c # d",
+		"EMPTY":       "",
+	}
+
+	if len(got) != len(want) {
+		t.Errorf("Expected %d entries, got %v", len(want), got)
+	}
+	for key, wantVal := range want {
+		gotVal, ok := got[key]
+		if !ok {
+			t.Errorf("Expected %s to be present", key)
+		} else if gotVal != wantVal {
+			t.Errorf("%s: Expected %q, got %q", key, wantVal, gotVal)
+		}
+	}
+}
+
+func TestDefaultEnvFile(t *testing.T) {
+
+	type Config struct {
+		Foo string `env:"DEFAULT_ENV_FOO" default:"unset"`
+	}
+
+	dir := t.TempDir()
+	present := filepath.Join(dir, "present.env")
+	if err := os.WriteFile(present, []byte("DEFAULT_ENV_FOO=from-default\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	explicit := filepath.Join(dir, "explicit.env")
+	if err := os.WriteFile(explicit, []byte("DEFAULT_ENV_FOO=from-flag\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		name        string
+		defaultFile string
+		args        []string
+		expected    string
+		expectErr   bool
+	}{{
+		name:        "present",
+		defaultFile: present,
+		expected:    "from-default",
+	}, {
+		name:        "absent",
+		defaultFile: filepath.Join(dir, "absent.env"),
+		expected:    "unset",
+	}, {
+		name: "unreadable",
+		// a directory exists, but can't be read as a file
+		defaultFile: dir,
+		expectErr:   true,
+	}, {
+		name:        "explicit flag wins",
+		defaultFile: present,
+		args:        []string{"--envfile", explicit},
+		expected:    "from-flag",
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			// restores the env after the file sets it
+			t.Setenv("DEFAULT_ENV_FOO", "")
+			os.Unsetenv("DEFAULT_ENV_FOO")
+
+			cfg := &Config{}
+			err := ParseCombined(reflect.ValueOf(cfg), tc.args, WithDefaultEnvFile(tc.defaultFile))
+			if tc.expectErr {
+				if err == nil {
+					t.Fatalf("Expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if cfg.Foo != tc.expected {
+				t.Errorf("Expected %q, got %q", tc.expected, cfg.Foo)
+			}
+		})
+	}
+}
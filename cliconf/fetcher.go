@@ -0,0 +1,60 @@
+package cliconf
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+// ConfigFetcher reads the contents of env and config files given as a URI,
+// e.g. `--config s3://bucket/app.yaml`, keeping cloud dependencies out of
+// cliconf.
+type ConfigFetcher interface {
+	Fetch(uri string) ([]byte, error)
+}
+
+// ConfigFetcherFunc adapts a function to a ConfigFetcher
+type ConfigFetcherFunc func(uri string) ([]byte, error)
+
+func (cf ConfigFetcherFunc) Fetch(uri string) ([]byte, error) {
+	return cf(uri)
+}
+
+var configFetchers = struct {
+	sync.RWMutex
+	byScheme map[string]ConfigFetcher
+}{
+	byScheme: map[string]ConfigFetcher{},
+}
+
+// RegisterConfigFetcher sets the fetcher for URIs with the scheme, e.g. "s3"
+// for `s3://bucket/key`, used by --envfile, --config, LoadEnvFile and
+// ReadEnvFile. Paths without a scheme, and `file://` URIs, are read from the
+// local filesystem unless a fetcher is registered for "file".
+func RegisterConfigFetcher(scheme string, fetcher ConfigFetcher) {
+	configFetchers.Lock()
+	defer configFetchers.Unlock()
+	configFetchers.byScheme[scheme] = fetcher
+}
+
+// fetchConfig reads the file or URI with the registered fetcher for the
+// scheme
+func fetchConfig(uri string) ([]byte, error) {
+	scheme, path, ok := strings.Cut(uri, "://")
+	if !ok {
+		return os.ReadFile(uri)
+	}
+
+	configFetchers.RLock()
+	fetcher, registered := configFetchers.byScheme[scheme]
+	configFetchers.RUnlock()
+
+	if registered {
+		return fetcher.Fetch(uri)
+	}
+	if scheme == "file" {
+		return os.ReadFile(path)
+	}
+	return nil, fmt.Errorf("no config fetcher registered for %s://", scheme)
+}
@@ -0,0 +1,63 @@
+package cliconf
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestConfigFetcher(t *testing.T) {
+
+	files := map[string]string{
+		"mem://app.yaml": "name: from-memory\n",
+		"mem://app.env":  "RUNNER_TEST_FETCH_LEVEL=debug\n",
+	}
+	RegisterConfigFetcher("mem", ConfigFetcherFunc(func(uri string) ([]byte, error) {
+		data, ok := files[uri]
+		if !ok {
+			return nil, fmt.Errorf("%s: %w", uri, os.ErrNotExist)
+		}
+		return []byte(data), nil
+	}))
+
+	type Config struct {
+		Name  string `flag:"name"`
+		Level string `env:"RUNNER_TEST_FETCH_LEVEL"`
+	}
+
+	t.Cleanup(func() {
+		os.Unsetenv("RUNNER_TEST_FETCH_LEVEL")
+	})
+
+	cfg := &Config{}
+	err := ParseCombined(reflect.ValueOf(cfg), []string{"--config", "mem://app.yaml", "--envfile", "mem://app.env"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if cfg.Name != "from-memory" || cfg.Level != "debug" {
+		t.Errorf("Expected values from the fetcher, got %+v", cfg)
+	}
+
+	t.Run("local file URI", func(t *testing.T) {
+		envFilename := filepath.Join(t.TempDir(), ".env")
+		if err := os.WriteFile(envFilename, []byte("KEY=value\n"), 0600); err != nil {
+			t.Fatal(err)
+		}
+		env, err := ReadEnvFile("file://" + envFilename)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if env["KEY"] != "value" {
+			t.Errorf("Expected KEY=value, got %v", env)
+		}
+	})
+
+	t.Run("unregistered scheme", func(t *testing.T) {
+		_, err := ReadEnvFile("nope://app.env")
+		if err == nil || err.Error() != "no config fetcher registered for nope://" {
+			t.Errorf("Expected unregistered scheme error, got %v", err)
+		}
+	})
+}
@@ -2,6 +2,7 @@ package cliconf
 
 import (
 	"fmt"
+	"slices"
 	"strings"
 )
 
@@ -10,7 +11,41 @@ const (
 	boolFalse = "false"
 )
 
-func parseFlags(src []string, booleans map[string]struct{}) (map[string]string, []string, error) {
+// flagResolver maps the name of a flag as given to the name it is known by.
+type flagResolver func(name string) (string, error)
+
+// prefixResolver resolves unambiguous prefixes of names to the full name, as
+// with GNU getopt. An exact match always wins, and names which match nothing
+// are returned as-is, to be reported as unknown.
+func prefixResolver(names []string) flagResolver {
+	return func(name string) (string, error) {
+		candidates := make([]string, 0)
+		for _, candidate := range names {
+			if candidate == name {
+				return name, nil
+			}
+			if strings.HasPrefix(candidate, name) {
+				candidates = append(candidates, candidate)
+			}
+		}
+		switch len(candidates) {
+		case 0:
+			return name, nil
+		case 1:
+			return candidates[0], nil
+		}
+		slices.Sort(candidates)
+		for idx, candidate := range candidates {
+			candidates[idx] = "--" + candidate
+		}
+		return "", fmt.Errorf("ambiguous flag, could be %s", strings.Join(candidates, ", "))
+	}
+}
+
+// parseFlags splits the leading flags from the plain args, which start at the
+// first arg not starting with -, or after a `--` terminator. resolve may be
+// nil to match flags exactly.
+func parseFlags(src []string, booleans map[string]struct{}, resolve flagResolver) (map[string]string, []string, error) {
 	flagMap := make(map[string]string)
 
 	for len(src) > 0 {
@@ -20,10 +55,30 @@ func parseFlags(src []string, booleans map[string]struct{}) (map[string]string,
 			// plain args
 			return flagMap, src, nil
 		}
+		if arg == "--" {
+			// everything after the terminator is a plain arg, even when it
+			// starts with -
+			return flagMap, src[1:], nil
+		}
 		arg = strings.TrimPrefix(arg, "-")
 		arg = strings.TrimPrefix(arg, "-")
 		src = src[1:]
 
+		if resolve != nil {
+			given, value, hasValue := strings.Cut(arg, "=")
+			name, err := resolve(given)
+			if err != nil {
+				return nil, nil, ParamErrors{{
+					Flag: given,
+					Err:  err,
+				}}
+			}
+			arg = name
+			if hasValue {
+				arg += "=" + value
+			}
+		}
+
 		if _, ok := booleans[arg]; ok {
 			if len(src) == 0 || strings.HasPrefix(src[0], "-") {
 				flagMap[arg] = "true"
@@ -40,6 +95,10 @@ func parseFlags(src []string, booleans map[string]struct{}) (map[string]string,
 			if lower == boolTrue || lower == boolFalse {
 				flagMap[arg] = lower
 				src = src[1:]
+			} else {
+				// The next arg is the first positional, the flag itself is
+				// still set
+				flagMap[arg] = boolTrue
 			}
 
 			continue
@@ -65,3 +124,35 @@ func parseFlags(src []string, booleans map[string]struct{}) (map[string]string,
 
 	return flagMap, []string{}, nil
 }
+
+// collectUnknownFlags returns the flags left in flagMap as `--key value`
+// pairs, in the order they appeared in args, for forwarding to another
+// command.
+func collectUnknownFlags(args []string, flagMap map[string]string) []string {
+	out := make([]string, 0, len(flagMap)*2)
+	seen := make(map[string]struct{}, len(flagMap))
+	for _, arg := range args {
+		if arg == "--" {
+			break
+		}
+		if !strings.HasPrefix(arg, "-") {
+			continue
+		}
+		dashes := "-"
+		if strings.HasPrefix(arg, "--") {
+			dashes = "--"
+		}
+		name := strings.TrimPrefix(arg, dashes)
+		name, _, _ = strings.Cut(name, "=")
+		val, ok := flagMap[name]
+		if !ok {
+			continue
+		}
+		if _, ok := seen[name]; ok {
+			continue
+		}
+		seen[name] = struct{}{}
+		out = append(out, dashes+name, val)
+	}
+	return out
+}
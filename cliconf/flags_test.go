@@ -31,13 +31,33 @@ func TestCommandFlagParse(t *testing.T) {
 			"b3":  "true",
 		},
 		expectedRemaining: []string{"f1", "f2"},
+	}, {
+		name:              "empty value",
+		src:               []string{"--foo=", "--bar", ""},
+		expected:          map[string]string{"foo": "", "bar": ""},
+		expectedRemaining: []string{},
+	}, {
+		name:              "terminator",
+		src:               []string{"--foo", "foo", "--", "--bar=bar", "-x"},
+		expected:          map[string]string{"foo": "foo"},
+		expectedRemaining: []string{"--bar=bar", "-x"},
+	}, {
+		name:              "bool before positional",
+		src:               []string{"--b1", "f1", "--b2"},
+		expected:          map[string]string{"b1": "true"},
+		expectedRemaining: []string{"f1", "--b2"},
+	}, {
+		name:              "bool value before positional",
+		src:               []string{"--b1", "false", "f1"},
+		expected:          map[string]string{"b1": "false"},
+		expectedRemaining: []string{"f1"},
 	}, {
 		name:     "bool at end",
 		src:      []string{"--b1"},
 		expected: map[string]string{"b1": "true"},
 	}} {
 		t.Run(tc.name, func(t *testing.T) {
-			got, gotRemaining, err := parseFlags(tc.src, booleans)
+			got, gotRemaining, err := parseFlags(tc.src, booleans, nil)
 			if err != nil {
 				t.Errorf("Expected no error, got %v", err)
 			}
@@ -47,8 +67,11 @@ func TestCommandFlagParse(t *testing.T) {
 			}
 
 			for k, v := range tc.expected {
-				if got[k] != v {
-					t.Errorf("Expected %v for %v, got %v", v, k, got[k])
+				gotVal, ok := got[k]
+				if !ok {
+					t.Errorf("Expected %v to be present", k)
+				} else if gotVal != v {
+					t.Errorf("Expected %v for %v, got %v", v, k, gotVal)
 				}
 			}
 
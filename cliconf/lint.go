@@ -0,0 +1,135 @@
+package cliconf
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// Lint checks that the tags of a config struct are coherent, returning every
+// problem found, e.g. for a unit test asserting that a CLI's config is valid.
+// Parsing fails on most of these too, but only once a command runs, and only
+// on the first problem.
+//
+// As well as the tags themselves, Lint checks that flag names are unique,
+// that positional args are numbered without gaps, that `required:"when=..."`
+// conditions reference a field and aren't combined with a default, that
+// `together` groups have more than one field, and that defaults parse and
+// validate.
+func Lint(rt reflect.Type) []error {
+	if rt.Kind() == reflect.Pointer {
+		rt = rt.Elem()
+	}
+	if rt.Kind() != reflect.Struct {
+		return []error{fmt.Errorf("expected struct, got %v", rt.Kind())}
+	}
+
+	fields, err := findStructFields(reflect.New(rt).Elem())
+	if err != nil {
+		// Tags are checked by structField, which stops at the first error
+		return []error{err}
+	}
+
+	errs := make([]error, 0)
+	byName := make(map[string]*field, len(fields))
+	flagNames := map[string]*field{}
+	argMap := map[int]*field{}
+	together := map[string]int{}
+	var argRange, remaining, unknownFlags, stdinField *field
+
+	for _, field := range fields {
+		byName[field.fieldName] = field
+
+		if field.flagName != "" {
+			if existing, ok := flagNames[field.flagName]; ok {
+				errs = append(errs, fmt.Errorf("flag --%s is used by both %s and %s", field.flagName, existing.fieldName, field.fieldName))
+			} else {
+				flagNames[field.flagName] = field
+			}
+		}
+
+		if field.stdin {
+			if stdinField != nil {
+				errs = append(errs, fmt.Errorf("only one field can read from stdin, %s and %s both set stdin", stdinField.fieldName, field.fieldName))
+			}
+			stdinField = field
+		}
+
+		switch {
+		case field.argRange:
+			if argRange != nil {
+				errs = append(errs, fmt.Errorf("only one field can be tagged with an arg range, %s and %s both are", argRange.fieldName, field.fieldName))
+			}
+			argRange = field
+		case field.argn != nil:
+			if existing, ok := argMap[*field.argn]; ok {
+				errs = append(errs, fmt.Errorf("arg%d is used by both %s and %s", *field.argn, existing.fieldName, field.fieldName))
+			}
+			argMap[*field.argn] = field
+		case field.remaining:
+			if remaining != nil {
+				errs = append(errs, fmt.Errorf("only one field can be tagged with ,remaining or ,passthrough, %s and %s both are", remaining.fieldName, field.fieldName))
+			}
+			remaining = field
+		case field.unknown:
+			if unknownFlags != nil {
+				errs = append(errs, fmt.Errorf("only one field can be tagged with ,unknown, %s and %s both are", unknownFlags.fieldName, field.fieldName))
+			}
+			unknownFlags = field
+		}
+
+		if field.together != "" {
+			together[field.together]++
+		}
+
+		if field.requiredIf != nil && field.defaultVal != nil {
+			errs = append(errs, fmt.Errorf("field %s: required when %s has no effect with a default", field.fieldName, field.requiredIf))
+		}
+
+		if err := lintDefault(field); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if err := checkArgIndexes(argMap, argRange); err != nil {
+		errs = append(errs, err)
+	}
+
+	if argRange != nil && remaining != nil {
+		errs = append(errs, fmt.Errorf("an arg range and ,remaining can't be used together, %s and %s", argRange.fieldName, remaining.fieldName))
+	}
+
+	for _, field := range fields {
+		if field.requiredIf == nil {
+			continue
+		}
+		if _, ok := byName[field.requiredIf.fieldName]; !ok {
+			errs = append(errs, fmt.Errorf("field %s: condition references unknown field %s", field.fieldName, field.requiredIf.fieldName))
+		}
+	}
+
+	for _, field := range fields {
+		if count := together[field.together]; count == 1 {
+			errs = append(errs, fmt.Errorf("field %s: together group %q has no other fields", field.fieldName, field.together))
+		}
+	}
+
+	return errs
+}
+
+// lintDefault checks that the default of the field parses and validates.
+// Defaults which are expanded, or read from a file or stdin, are only known
+// when parsing.
+func lintDefault(field *field) error {
+	if field.defaultVal == nil || field.expandDefault || field.fromFile || field.stdin {
+		return nil
+	}
+
+	err := setFieldValue(newParseOptions(nil), field, *field.defaultVal)
+	if err == nil {
+		err = validateField(field)
+	}
+	if err != nil {
+		return fmt.Errorf("field %s: default %q: %w", field.fieldName, *field.defaultVal, err)
+	}
+	return nil
+}
@@ -0,0 +1,109 @@
+package cliconf
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLint(t *testing.T) {
+
+	type Inner struct {
+		Name string `flag:"name"`
+	}
+
+	type Valid struct {
+		Name   string   `flag:"name" env:"NAME" default:"app"`
+		Port   int      `flag:"port" default:"8080" validate:"min=1"`
+		Mode   string   `flag:"mode" default:"dev"`
+		Key    string   `flag:"key" required:"when=Mode==prod"`
+		Target string   `flag:",arg0"`
+		Rest   []string `flag:",remaining"`
+	}
+
+	type DuplicateFlags struct {
+		Name  string `flag:"name"`
+		Inner Inner
+	}
+
+	type ArgGap struct {
+		First string `flag:",arg0"`
+		Third string `flag:",arg2"`
+	}
+
+	type DuplicateArgs struct {
+		First  string `flag:",arg0"`
+		Second string `flag:",arg0"`
+	}
+
+	type BadRemaining struct {
+		Rest []int `flag:",remaining"`
+	}
+
+	type RequiredWithDefault struct {
+		Mode string `flag:"mode"`
+		Key  string `flag:"key" default:"k" required:"when=Mode==prod"`
+		Cert string `flag:"cert" required:"when=Missing==true"`
+	}
+
+	type BadDefaults struct {
+		Port    int    `flag:"port" default:"eighty"`
+		Workers int    `flag:"workers" default:"0" validate:"min=1"`
+		User    string `flag:"user" together:"auth"`
+	}
+
+	for _, tc := range []struct {
+		name string
+		rt   reflect.Type
+		want []string
+	}{{
+		name: "valid",
+		rt:   reflect.TypeOf(&Valid{}),
+		want: []string{},
+	}, {
+		name: "duplicate flags",
+		rt:   reflect.TypeOf(DuplicateFlags{}),
+		want: []string{"flag --name is used by both Name and Inner.Name"},
+	}, {
+		name: "arg gap",
+		rt:   reflect.TypeOf(ArgGap{}),
+		want: []string{"positional args must be numbered from arg0 without gaps, arg1 is missing"},
+	}, {
+		name: "duplicate args",
+		rt:   reflect.TypeOf(DuplicateArgs{}),
+		want: []string{"arg0 is used by both First and Second"},
+	}, {
+		name: "bad remaining",
+		rt:   reflect.TypeOf(BadRemaining{}),
+		want: []string{"remaining args must be a slice of strings"},
+	}, {
+		name: "required with default",
+		rt:   reflect.TypeOf(RequiredWithDefault{}),
+		want: []string{
+			"field Key: required when Mode is prod has no effect with a default",
+			"field Cert: condition references unknown field Missing",
+		},
+	}, {
+		name: "bad defaults",
+		rt:   reflect.TypeOf(BadDefaults{}),
+		want: []string{
+			`field Port: default "eighty": strconv.ParseInt: parsing "eighty": invalid syntax`,
+			`field Workers: default "0": must be at least 1`,
+			`field User: together group "auth" has no other fields`,
+		},
+	}, {
+		name: "not a struct",
+		rt:   reflect.TypeOf(""),
+		want: []string{"expected struct, got string"},
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			errs := Lint(tc.rt)
+			got := make([]string, len(errs))
+			for idx, err := range errs {
+				got[idx] = err.Error()
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("Expected %q\n     got %q", tc.want, got)
+			}
+		})
+	}
+}
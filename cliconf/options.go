@@ -0,0 +1,122 @@
+package cliconf
+
+import (
+	"context"
+	"io"
+	"os"
+)
+
+type parseOptions struct {
+	ctx      context.Context
+	warnings io.Writer
+	stdin    io.Reader
+	sources  []ValueSource
+	prompt   func(PromptField) (string, bool)
+
+	defaultEnvFile     string
+	caseInsensitiveEnv bool
+	argsFiles          bool
+	flagPrefixes       bool
+}
+
+// ParseOption configures ParseCombined
+type ParseOption func(*parseOptions)
+
+// WithWarningWriter sets where warnings, such as use of deprecated flags, are
+// written. Defaults to os.Stderr.
+func WithWarningWriter(w io.Writer) ParseOption {
+	return func(po *parseOptions) {
+		po.warnings = w
+	}
+}
+
+// WithStdin sets the reader used for fields tagged `stdin:"true"` when their
+// value is "-". Defaults to os.Stdin.
+func WithStdin(r io.Reader) ParseOption {
+	return func(po *parseOptions) {
+		po.stdin = r
+	}
+}
+
+// WithValueSources adds sources which are consulted, in order, for any field
+// not set by a flag. The full precedence is:
+// flag > sources (in order) > env > config file > default
+func WithValueSources(sources ...ValueSource) ParseOption {
+	return func(po *parseOptions) {
+		po.sources = append(po.sources, sources...)
+	}
+}
+
+// PromptField describes a required field with no value, for prompting the
+// user to enter it.
+type PromptField struct {
+	// Name is the flag or env var name as it would be displayed in help
+	Name        string
+	Description string
+	Secret      bool
+}
+
+// WithPrompt sets a function which is called for each required field which
+// has no value from any source. If it returns false, the field is reported
+// as required in the same way as without a prompt.
+func WithPrompt(prompt func(PromptField) (string, bool)) ParseOption {
+	return func(po *parseOptions) {
+		po.prompt = prompt
+	}
+}
+
+// WithDefaultEnvFile loads the env file at path, e.g. /etc/myapp.env, when
+// --envfile is not given. Unlike --envfile, a missing file is ignored, but a
+// file which exists and can't be read is an error.
+func WithDefaultEnvFile(path string) ParseOption {
+	return func(po *parseOptions) {
+		po.defaultEnvFile = path
+	}
+}
+
+// WithCaseInsensitiveEnv falls back to matching env vars regardless of case
+// when the exact name is not set, e.g. `env:"FOO"` is read from `foo`. Each
+// fallback scans the whole environment.
+func WithCaseInsensitiveEnv() ParseOption {
+	return func(po *parseOptions) {
+		po.caseInsensitiveEnv = true
+	}
+}
+
+// WithArgsFiles expands args starting with @ into the contents of the named
+// file before parsing, see ExpandArgsFiles.
+func WithArgsFiles() ParseOption {
+	return func(po *parseOptions) {
+		po.argsFiles = true
+	}
+}
+
+// WithFlagPrefixes accepts any unambiguous prefix of a flag name, e.g.
+// `--verb` for `--verbose`. An ambiguous prefix is an error listing the
+// candidates.
+func WithFlagPrefixes() ParseOption {
+	return func(po *parseOptions) {
+		po.flagPrefixes = true
+	}
+}
+
+// WithContext sets the context passed to custom types implementing
+// SetterFromRunnerContext, e.g. to cancel a remote lookup. Defaults to
+// context.Background.
+func WithContext(ctx context.Context) ParseOption {
+	return func(po *parseOptions) {
+		po.ctx = ctx
+	}
+}
+
+func newParseOptions(options []ParseOption) *parseOptions {
+	po := &parseOptions{
+		ctx:      context.Background(),
+		warnings: os.Stderr,
+		stdin:    os.Stdin,
+	}
+	for _, option := range options {
+		option(po)
+	}
+	return po
+}
@@ -4,14 +4,19 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"os"
 	"reflect"
+	"slices"
 	"strings"
+	"time"
 )
 
+// ParamError is a failure to parse or validate a single field.
 type ParamError struct {
 	Flag      string
 	Env       string
+	ArgN      *int
 	FieldName string
 	Err       error
 }
@@ -20,6 +25,14 @@ func (pe ParamError) Error() string {
 	return fmt.Sprintf("Error parsing %s: %s", pe.FieldName, pe.Err)
 }
 
+func (pe ParamError) Unwrap() error {
+	return pe.Err
+}
+
+// ParamErrors is returned by ParseCombined when one or more fields fail to
+// parse or validate. It is a plain slice, so callers can use errors.As to
+// retrieve it and inspect each ParamError. Errors returned by commander
+// Commands wrap it, so errors.As works from there too.
 type ParamErrors []ParamError
 
 func (pe ParamErrors) Error() string {
@@ -31,25 +44,113 @@ func (pe ParamErrors) Error() string {
 	return out
 }
 
+// Unwrap allows errors.Is and errors.As to match the error of any field
+func (pe ParamErrors) Unwrap() []error {
+	errs := make([]error, len(pe))
+	for idx, err := range pe {
+		errs[idx] = err
+	}
+	return errs
+}
+
+// ErrorsByField returns the errors keyed by the Go field name, e.g. "Foo" or
+// "Nested.Foo". Errors which don't belong to a field, such as unknown flags,
+// are keyed by the flag, e.g. "--foo". Multiple errors for the same key are
+// joined.
+func (pe ParamErrors) ErrorsByField() map[string]error {
+	byField := make(map[string]error, len(pe))
+	for _, err := range pe {
+		key := err.FieldName
+		if key == "" && err.Flag != "" {
+			key = "--" + err.Flag
+		}
+		if existing, ok := byField[key]; ok {
+			byField[key] = errors.Join(existing, err.Err)
+		} else {
+			byField[key] = err.Err
+		}
+	}
+	return byField
+}
+
+// ParseResult records which fields were set while parsing.
+type ParseResult struct {
+	provided map[string]bool
+	origins  map[string]Origin
+}
+
+// Origin is where the value of a field came from
+type Origin string
+
+const (
+	OriginFlag       Origin = "flag"
+	OriginSource     Origin = "source"
+	OriginEnv        Origin = "env"
+	OriginConfigFile Origin = "config file"
+	OriginArg        Origin = "arg"
+	OriginPrompt     Origin = "prompt"
+	OriginDefault    Origin = "default"
+)
+
+// Provided returns true when the field was set by a flag, value source, env
+// var, config file, positional arg or prompt. Fields which took their default
+// value, or were not set at all, are not provided. Fields are named by their Go
+// field name, e.g. "Foo" or "Nested.Foo".
+func (pr *ParseResult) Provided(fieldName string) bool {
+	return pr.provided[fieldName]
+}
+
+// Origin returns where the value of the field came from, e.g. to debug which
+// of a flag, env var, config file and default won. Fields which weren't set
+// at all, or which were skipped because their struct was set as a whole, have
+// an empty Origin. Plain booleans which weren't set have OriginDefault.
+func (pr *ParseResult) Origin(fieldName string) Origin {
+	return pr.origins[fieldName]
+}
+
 const envFileFlag = "envfile"
 
-func ParseCombined(rvRaw reflect.Value, args []string) error {
+// ErrNoOptions is returned, in a ParamError, when args are passed to a struct
+// with no fields to parse them into.
+var ErrNoOptions = errors.New("this command takes no options")
+
+func ParseCombined(rvRaw reflect.Value, args []string, options ...ParseOption) error {
+	_, err := ParseWithResult(rvRaw, args, options...)
+	return err
+}
+
+// ParseWithResult parses in the same way as ParseCombined, also returning which
+// fields were provided. The result is returned along with any ParamErrors, but
+// is nil when the struct itself is invalid or the args can't be parsed.
+func ParseWithResult(rvRaw reflect.Value, args []string, options ...ParseOption) (*ParseResult, error) {
+	opts := newParseOptions(options)
+
 	rv, err := toStructVal(rvRaw)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	fields, err := findStructFields(rv)
 	if err != nil {
-		return err
+		return nil, err
+	}
+
+	if len(fields) == 0 && len(args) > 0 {
+		return &ParseResult{}, ParamErrors{{
+			Err: fmt.Errorf("%w, got %q", ErrNoOptions, args),
+		}}
 	}
 
 	argMap := map[int]*field{}
+	var argRange *field
 	var remaining *field
+	var unknownFlags *field
 	booleans := map[string]struct{}{}
 	flagEnvFields := make([]*field, 0, len(fields))
 
 	hasEnvFileFlag := false
+	hasConfigFileFlag := false
+	var stdinField *field
 
 	for _, field := range fields {
 		if field.isBool {
@@ -60,23 +161,83 @@ func ParseCombined(rvRaw reflect.Value, args []string) error {
 			hasEnvFileFlag = true
 		}
 
-		if field.argn != nil {
+		if field.flagName == configFileFlag {
+			hasConfigFileFlag = true
+		}
+
+		if field.stdin {
+			if stdinField != nil {
+				return nil, fmt.Errorf("only one field can read from stdin, %s and %s both set stdin", stdinField.fieldName, field.fieldName)
+			}
+			stdinField = field
+		}
+
+		if field.argRange {
+			if argRange != nil {
+				return nil, fmt.Errorf("only one field can be tagged with an arg range")
+			}
+			argRange = field
+		} else if field.argn != nil {
+			if existing, ok := argMap[*field.argn]; ok {
+				return nil, fmt.Errorf("arg%d is used by both %s and %s", *field.argn, existing.fieldName, field.fieldName)
+			}
 			argMap[*field.argn] = field
+			if field.argFallback {
+				flagEnvFields = append(flagEnvFields, field)
+			}
 		} else if field.remaining {
 			if remaining != nil {
-				return fmt.Errorf("only one field can be tagged with ,remaining")
+				return nil, fmt.Errorf("only one field can be tagged with ,remaining or ,passthrough")
 			}
 			remaining = field
+		} else if field.unknown {
+			if unknownFlags != nil {
+				return nil, fmt.Errorf("only one field can be tagged with ,unknown")
+			}
+			unknownFlags = field
 		} else if field.flagName != "" || field.envName != "" {
 			flagEnvFields = append(flagEnvFields, field)
 		} else {
-			return fmt.Errorf("field %s has no flag, env, argn, or remaining tag", field.fieldName)
+			return nil, fmt.Errorf("field %s has no flag, env, argn, or remaining tag", field.fieldName)
+		}
+	}
+
+	if err := checkArgIndexes(argMap, argRange); err != nil {
+		return nil, err
+	}
+
+	if argRange != nil && remaining != nil {
+		return nil, fmt.Errorf("an arg range and ,remaining can't be used together, %s and %s", argRange.fieldName, remaining.fieldName)
+	}
+
+	var resolve flagResolver
+	if opts.flagPrefixes {
+		flagNames := make([]string, 0, len(flagEnvFields)+2)
+		for _, field := range flagEnvFields {
+			if field.flagName != "" {
+				flagNames = append(flagNames, field.flagName)
+			}
+		}
+		if !hasEnvFileFlag {
+			flagNames = append(flagNames, envFileFlag)
+		}
+		if !hasConfigFileFlag {
+			flagNames = append(flagNames, configFileFlag)
+		}
+		resolve = prefixResolver(flagNames)
+	}
+
+	if opts.argsFiles {
+		expander := &argsExpander{booleans: booleans, resolve: resolve}
+		args, err = expander.expand(args, nil)
+		if err != nil {
+			return nil, err
 		}
 	}
 
-	flagMap, remainingArgs, err := parseFlags(args, booleans)
+	flagMap, remainingArgs, err := parseFlags(args, booleans, resolve)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	// load the env file IFF it is set AND the struct doesn't have its own.
@@ -85,25 +246,51 @@ func ParseCombined(rvRaw reflect.Value, args []string) error {
 			delete(flagMap, "envfile")
 			err := LoadEnvFile(envFile)
 			if err != nil {
-				return err
+				return nil, err
+			}
+		} else if opts.defaultEnvFile != "" {
+			if err := loadOptionalEnvFile(opts.defaultEnvFile); err != nil {
+				return nil, err
 			}
 		}
 	}
 
 	dd := &cmdData{
-		flagMap: flagMap,
+		flagMap:            flagMap,
+		sources:            opts.sources,
+		caseInsensitiveEnv: opts.caseInsensitiveEnv,
+	}
+
+	// as with the env file, the config file flag is only used when the struct
+	// doesn't define its own.
+	if !hasConfigFileFlag {
+		if configFilename, ok := flagMap[configFileFlag]; ok {
+			delete(flagMap, configFileFlag)
+			dd.configFile, err = readConfigFile(configFilename)
+			if err != nil {
+				return nil, err
+			}
+		}
 	}
 
 	flagErr := make(ParamErrors, 0)
 	thenRemainingArgs := make([]string, 0, len(remainingArgs))
+	rangeArgs := make([]string, 0)
 	for idx, arg := range remainingArgs {
+		if argRange != nil && idx >= *argRange.argn {
+			rangeArgs = append(rangeArgs, arg)
+			continue
+		}
 		argField, ok := argMap[idx]
 		if ok {
-			err = setFieldValue(argField, arg)
+			argField.provide(OriginArg)
+			err = setFieldValue(opts, argField, arg)
+			if err == nil {
+				err = validateField(argField)
+			}
 			if err != nil {
 				flagErr = append(flagErr, ParamError{
-					Flag:      argField.flagName,
-					Env:       argField.envName,
+					ArgN:      argField.argn,
 					FieldName: argField.fieldName,
 					Err:       err,
 				})
@@ -113,22 +300,102 @@ func ParseCombined(rvRaw reflect.Value, args []string) error {
 		}
 	}
 
+	if len(rangeArgs) > 0 {
+		argRange.provide(OriginArg)
+		err = setSliceFromStrings(opts.ctx, argRange.fieldVal, rangeArgs)
+		if err == nil {
+			err = validateField(argRange)
+		}
+		if err != nil {
+			flagErr = append(flagErr, ParamError{
+				ArgN:      argRange.argn,
+				FieldName: argRange.fieldName,
+				Err:       err,
+			})
+		}
+	}
+
+	// checkArgIndexes ensures the indexes are contiguous, so missing args are
+	// reported in order
+	minArgs := 0
+	for idx := 0; idx < len(argMap); idx++ {
+		if !argMap[idx].optional && !argMap[idx].argFallback {
+			minArgs = idx + 1
+		}
+	}
+	if argRange != nil && !argRange.optional {
+		minArgs = *argRange.argn + 1
+	}
+
+	missingArgErr := func(argField *field) {
+		// Fallback args are required as flags instead
+		if argField.provided || argField.optional || argField.argFallback {
+			return
+		}
+		flagErr = append(flagErr, ParamError{
+			ArgN:      argField.argn,
+			FieldName: argField.fieldName,
+			Err:       fmt.Errorf("required, got %d of %d required args", len(remainingArgs), minArgs),
+		})
+	}
+	for idx := 0; idx < len(argMap); idx++ {
+		missingArgErr(argMap[idx])
+	}
+	if argRange != nil {
+		missingArgErr(argRange)
+	}
+
 	if len(thenRemainingArgs) > 0 {
 		if remaining != nil {
-			remaining.fieldVal.Set(reflect.ValueOf(remainingArgs))
-		} else if len(remainingArgs) > 0 {
+			remaining.fieldVal.Set(reflect.ValueOf(thenRemainingArgs))
+		} else {
 			flagErr = append(flagErr, ParamError{
 				FieldName: "remaining",
-				Err:       errors.New("too many remaining args"),
+				Err:       fmt.Errorf("too many args, expected at most %d, got %d, unexpected %q", len(argMap), len(remainingArgs), thenRemainingArgs),
 			})
 		}
 	}
 
 	for _, field := range flagEnvFields {
 
+		// A struct set as a whole takes precedence over its subfields, which
+		// can't also be given as flags
+		if parent := field.providedParent(); parent != nil {
+			if _, ok := dd.flagMap[field.flagName]; ok && field.flagName != "" {
+				delete(dd.flagMap, field.flagName)
+				flagErr = append(flagErr, ParamError{
+					Flag:      field.flagName,
+					FieldName: field.fieldName,
+					Err:       fmt.Errorf("can't be used when %s sets the whole struct", parent.displayName()),
+				})
+			}
+			continue
+		}
+
+		// Fields given as a positional arg have already been set
+		if field.argFallback && field.provided {
+			if _, ok := dd.flagMap[field.flagName]; ok && field.flagName != "" {
+				delete(dd.flagMap, field.flagName)
+				flagErr = append(flagErr, ParamError{
+					Flag:      field.flagName,
+					ArgN:      field.argn,
+					FieldName: field.fieldName,
+					Err:       fmt.Errorf("given as both --%s and arg%d", field.flagName, *field.argn),
+				})
+			}
+			continue
+		}
+
 		stringPtr, err := dd.popValue(field)
 		if err != nil {
-			return err
+			return nil, err
+		}
+
+		if stringPtr == nil && !field.optional && opts.prompt != nil {
+			if val, ok := opts.prompt(field.promptField()); ok {
+				field.provide(OriginPrompt)
+				stringPtr = &val
+			}
 		}
 
 		if stringPtr == nil {
@@ -146,7 +413,10 @@ func ParseCombined(rvRaw reflect.Value, args []string) error {
 		}
 
 		stringValue := *stringPtr
-		err = setFieldValue(field, stringValue)
+		err = setFieldValue(opts, field, stringValue)
+		if err == nil {
+			err = validateField(field)
+		}
 		if err != nil {
 			flagErr = append(flagErr, ParamError{
 				Flag:      field.flagName,
@@ -157,44 +427,197 @@ func ParseCombined(rvRaw reflect.Value, args []string) error {
 		}
 	}
 
+	for _, field := range fields {
+		if field.provided && field.deprecated != "" {
+			fmt.Fprintf(opts.warnings, "Warning: %s is deprecated: %s\n", field.displayName(), field.deprecated)
+		}
+	}
+
+	flagErr = append(flagErr, checkTogether(fields)...)
+
+	requiredErrs, err := checkRequiredIf(fields)
+	if err != nil {
+		return nil, err
+	}
+	flagErr = append(flagErr, requiredErrs...)
+
+	if unknownFlags != nil {
+		unknownFlags.fieldVal.Set(reflect.ValueOf(collectUnknownFlags(args, dd.flagMap)))
+		dd.flagMap = map[string]string{}
+	}
+
 	for k := range dd.flagMap {
 		flagErr = append(flagErr, ParamError{
 			Err:  errors.New("unknown flag"),
 			Flag: k,
 		})
 	}
+	result := &ParseResult{
+		provided: make(map[string]bool, len(fields)),
+		origins:  make(map[string]Origin, len(fields)),
+	}
+	for _, field := range fields {
+		if field.provided {
+			result.provided[field.fieldName] = true
+		}
+		if field.origin != "" {
+			result.origins[field.fieldName] = field.origin
+		}
+	}
+
 	if len(flagErr) > 0 {
-		return flagErr
+		return result, flagErr
+	}
+	return result, nil
+}
+
+// checkArgIndexes ensures the positional args are numbered from 0 with no
+// gaps, and that any arg range starts after the last single arg. A gap would
+// otherwise leave an arg which can only be consumed by ,remaining.
+func checkArgIndexes(argMap map[int]*field, argRange *field) error {
+	for idx := 0; idx < len(argMap); idx++ {
+		if _, ok := argMap[idx]; !ok {
+			return fmt.Errorf("positional args must be numbered from arg0 without gaps, arg%d is missing", idx)
+		}
+	}
+	if argRange != nil && *argRange.argn != len(argMap) {
+		return fmt.Errorf("arg range for %s must start at arg%d, after the last positional arg", argRange.fieldName, len(argMap))
 	}
 	return nil
 }
 
 type cmdData struct {
-	flagMap map[string]string
+	flagMap            map[string]string
+	sources            []ValueSource
+	configFile         configFile
+	caseInsensitiveEnv bool
+}
+
+func (cd *cmdData) lookupEnv(name string) (string, bool) {
+	val, ok := os.LookupEnv(name)
+	if ok || !cd.caseInsensitiveEnv {
+		return val, ok
+	}
+	for _, kv := range os.Environ() {
+		key, val, _ := strings.Cut(kv, "=")
+		if strings.EqualFold(key, name) {
+			return val, true
+		}
+	}
+	return "", false
+}
+
+// lookupIndexedEnv returns the values of NAME_0, NAME_1 and so on, up to the
+// first which isn't set.
+func (cd *cmdData) lookupIndexedEnv(name string) []string {
+	var items []string
+	for idx := 0; ; idx++ {
+		val, ok := cd.lookupEnv(fmt.Sprintf("%s_%d", name, idx))
+		if !ok {
+			return items
+		}
+		items = append(items, val)
+	}
 }
 
+// lookupPrefixedEnv returns the env vars starting with NAME_, keyed by the
+// rest of the name in lower case, so LABEL_TEAM=core is "team": "core".
+func (cd *cmdData) lookupPrefixedEnv(name string) map[string]string {
+	prefix := name + "_"
+	items := map[string]string{}
+	for _, kv := range os.Environ() {
+		key, val, _ := strings.Cut(kv, "=")
+		if len(key) <= len(prefix) {
+			continue
+		}
+		matches := strings.HasPrefix(key, prefix)
+		if !matches && cd.caseInsensitiveEnv {
+			matches = strings.EqualFold(key[:len(prefix)], prefix)
+		}
+		if matches {
+			items[strings.ToLower(key[len(prefix):])] = val
+		}
+	}
+	return items
+}
+
+// popValue returns the value for the field from the highest precedence source
+// which has it, or nil if no source has a value. A flag is present when it is
+// in the flag map, even if its value is empty, so `--foo=` explicitly sets an
+// empty value which satisfies required fields and overrides any default.
 func (cd *cmdData) popValue(tag *field) (*string, error) {
 	if tag.flagName != "" {
 		val, ok := cd.flagMap[tag.flagName]
 		if ok {
 			delete(cd.flagMap, tag.flagName)
+			tag.provide(OriginFlag)
 			return &val, nil
 		}
 	}
 
+	if key := sourceKey(tag); key != "" {
+		for _, source := range cd.sources {
+			val, ok := source.Lookup(key)
+			if ok {
+				tag.provide(OriginSource)
+				return &val, nil
+			}
+		}
+	}
+
 	if tag.envName != "" {
-		val := os.Getenv(tag.envName)
-		if val != "" {
-			return &val, nil
+		// Exported but empty env vars are still set, e.g. to override a
+		// default with an empty value
+		for _, envName := range append([]string{tag.envName}, tag.envAliases...) {
+			if tag.envPrefix {
+				if items := cd.lookupPrefixedEnv(envName); len(items) > 0 {
+					tag.provide(OriginEnv)
+					tag.prefixedItems = items
+					joined := describeMap(reflect.ValueOf(items))
+					return &joined, nil
+				}
+				continue
+			}
+			if tag.indexed {
+				if items := cd.lookupIndexedEnv(envName); len(items) > 0 {
+					tag.provide(OriginEnv)
+					tag.indexedItems = items
+					joined := strings.Join(items, ",")
+					return &joined, nil
+				}
+			}
+			val, ok := cd.lookupEnv(envName)
+			if ok {
+				tag.provide(OriginEnv)
+				return &val, nil
+			}
+		}
+	}
+
+	if cd.configFile != nil {
+		val, err := cd.configFile.lookupField(tag)
+		if err != nil {
+			return nil, err
+		}
+		if val != nil {
+			tag.provide(OriginConfigFile)
+			return val, nil
 		}
 	}
 
-	if tag.isBool {
+	// Pointer booleans stay nil when not set
+	if tag.isBool && !tag.isPointer {
 		falseStr := "false"
+		tag.origin = OriginDefault
 		return &falseStr, nil
 	}
 
 	if tag.defaultVal != nil {
+		tag.origin = OriginDefault
+		if tag.expandDefault {
+			expanded := os.ExpandEnv(*tag.defaultVal)
+			return &expanded, nil
+		}
 		// if default is empty, that still works, e.g. empty string
 		return tag.defaultVal, nil
 	}
@@ -202,34 +625,109 @@ func (cd *cmdData) popValue(tag *field) (*string, error) {
 
 }
 
-func setFieldValue(field *field, stringValue string) error {
+// maxValueSnippet is the length values are truncated to in errors
+const maxValueSnippet = 40
 
-	fieldVal := field.fieldVal
+// valueSnippet quotes the start of the value for errors, secret values are
+// masked
+func (f *field) valueSnippet(value string) string {
+	if f.secret {
+		return "****"
+	}
+	if len(value) > maxValueSnippet {
+		return fmt.Sprintf("%q...", value[:maxValueSnippet])
+	}
+	return fmt.Sprintf("%q", value)
+}
 
-	fieldInterface := fieldVal.Addr().Interface()
+// readValueFile returns the path for values in the form @/path or file:/path
+func readValueFile(stringValue string) (string, bool) {
+	if strings.HasPrefix(stringValue, "@") {
+		return strings.TrimPrefix(stringValue, "@"), true
+	}
+	if strings.HasPrefix(stringValue, "file:") {
+		return strings.TrimPrefix(stringValue, "file:"), true
+	}
+	return "", false
+}
 
-	actualType := fieldVal.Kind()
-	if actualType == reflect.Pointer {
-		elemType := fieldVal.Type().Elem()
-		newVal := reflect.New(elemType)
-		fieldVal.Set(newVal)
-		fieldVal = newVal
-		actualType = fieldVal.Elem().Kind()
+func setFieldValue(opts *parseOptions, field *field, stringValue string) error {
+
+	if field.stdin && stringValue == "-" {
+		data, err := io.ReadAll(opts.stdin)
+		if err != nil {
+			return fmt.Errorf("reading value from stdin: %w", err)
+		}
+		stringValue = string(data)
+	} else if field.fromFile {
+		if filename, ok := readValueFile(stringValue); ok {
+			fileData, err := os.ReadFile(filename)
+			if err != nil {
+				return fmt.Errorf("reading value from file %s: %w", filename, err)
+			}
+			stringValue = strings.TrimSpace(string(fileData))
+		}
+	}
+
+	if field.indexedItems != nil {
+		// Each item is set as it is, items may contain commas
+		return setSliceFromStrings(opts.ctx, field.fieldVal, field.indexedItems)
+	}
+
+	if field.prefixedItems != nil {
+		return setMapFromStrings(opts.ctx, field.fieldVal, field.prefixedItems)
+	}
+
+	if field.pattern != nil && !field.pattern.MatchString(stringValue) {
+		if field.secret {
+			return fmt.Errorf("value does not match pattern %s", field.pattern)
+		}
+		return fmt.Errorf("%q does not match pattern %s", stringValue, field.pattern)
+	}
+
+	if field.enum != nil && !slices.Contains(field.enum, stringValue) {
+		return fmt.Errorf("%q is not one of: %s", stringValue, strings.Join(field.enum, ", "))
 	}
 
-	if actualType == reflect.Struct {
+	if field.encoding != "" {
+		return setEncodedBytes(field, stringValue)
+	}
+
+	if field.size {
+		return setByteSize(field, stringValue)
+	}
+
+	if field.percent {
+		return setPercent(field, stringValue)
+	}
+
+	// Pointers are only allocated once a value is set, so optional pointer
+	// fields stay nil when they are not provided
+	fieldVal := allocValue(field.fieldVal)
+
+	fieldInterface := fieldVal.Addr().Interface()
+	actualType := fieldVal.Kind()
+
+	// time.Time and custom setters are parsed from strings, other structs
+	// are JSON
+	_, isTime := fieldInterface.(*time.Time)
+	_, isSetter := fieldInterface.(SetterFromRunner)
+	if _, ok := fieldInterface.(SetterFromRunnerContext); ok {
+		isSetter = true
+	}
+	if actualType == reflect.Struct && !isTime && !isSetter {
 		if !strings.HasPrefix(stringValue, "{") {
-			return fmt.Errorf("struct fields should be set using JSON strings")
+			return fmt.Errorf("struct field %s should be set using a JSON object, got %s", field.fieldName, field.valueSnippet(stringValue))
 		}
 
 		if err := json.Unmarshal([]byte(stringValue), fieldInterface); err != nil {
-			return err
+			return fmt.Errorf("struct field %s: invalid JSON %s: %w", field.fieldName, field.valueSnippet(stringValue), err)
 		}
 
 		return nil
 	}
 
-	if err := SetFromString(fieldInterface, stringValue); err != nil {
+	if err := SetFromStringContext(opts.ctx, fieldInterface, stringValue); err != nil {
 		return err
 	}
 
@@ -1,7 +1,15 @@
 package cliconf
 
 import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
 	"reflect"
+	"slices"
+	"strings"
 	"testing"
 )
 
@@ -72,6 +80,30 @@ func TestParseEntry(t *testing.T) {
 			Foo: "foo",
 			Bar: "bar",
 		},
+	}, {
+		name: "empty flag overrides default",
+		args: []string{"--foo=foo", "--bar="},
+		expected: TestConfig{
+			Foo: "foo",
+			Bar: "",
+		},
+	}, {
+		name: "empty env overrides default",
+		args: []string{"--foo=foo"},
+		env: map[string]string{
+			"BAR": "",
+		},
+		expected: TestConfig{
+			Foo: "foo",
+			Bar: "",
+		},
+	}, {
+		name: "empty flag satisfies required",
+		args: []string{"--foo="},
+		expected: TestConfig{
+			Foo: "",
+			Bar: "bar",
+		},
 	}} {
 		t.Run(tc.name, func(t *testing.T) {
 
@@ -105,3 +137,1363 @@ func TestParseEntry(t *testing.T) {
 		})
 	}
 }
+
+func TestDeprecated(t *testing.T) {
+
+	type Config struct {
+		Name    string `flag:"name" optional:"true"`
+		OldName string `flag:"old-name" optional:"true" deprecated:"use --name instead"`
+	}
+
+	for _, tc := range []struct {
+		name        string
+		args        []string
+		wantWarning string
+	}{{
+		name: "not used",
+		args: []string{"--name=foo"},
+	}, {
+		name:        "used",
+		args:        []string{"--old-name=foo"},
+		wantWarning: "Warning: --old-name is deprecated: use --name instead\n",
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			warnings := &bytes.Buffer{}
+			cfg := &Config{}
+			if err := ParseCombined(reflect.ValueOf(cfg), tc.args, WithWarningWriter(warnings)); err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+
+			if warnings.String() != tc.wantWarning {
+				t.Errorf("Expected warning %q, got %q", tc.wantWarning, warnings.String())
+			}
+		})
+	}
+}
+
+func TestFromFile(t *testing.T) {
+
+	type Config struct {
+		Password string `flag:"password" env:"PASSWORD" fromfile:"true"`
+	}
+
+	secretFile := filepath.Join(t.TempDir(), "password")
+	if err := os.WriteFile(secretFile, []byte("hunter2\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		name string
+		args []string
+		want string
+	}{{
+		name: "at prefix",
+		args: []string{"--password=@" + secretFile},
+		want: "hunter2",
+	}, {
+		name: "file prefix",
+		args: []string{"--password=file:" + secretFile},
+		want: "hunter2",
+	}, {
+		name: "literal",
+		args: []string{"--password=hunter3"},
+		want: "hunter3",
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := &Config{}
+			if err := ParseCombined(reflect.ValueOf(cfg), tc.args); err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if cfg.Password != tc.want {
+				t.Errorf("Expected %q, got %q", tc.want, cfg.Password)
+			}
+		})
+	}
+
+	t.Run("missing file", func(t *testing.T) {
+		missingFile := filepath.Join(t.TempDir(), "missing")
+		err := ParseCombined(reflect.ValueOf(&Config{}), []string{"--password=@" + missingFile})
+		paramErrors := ParamErrors{}
+		if !errors.As(err, &paramErrors) {
+			t.Fatalf("Expected ParamErrors, got %v", err)
+		}
+		if len(paramErrors) != 1 || paramErrors[0].FieldName != "Password" {
+			t.Fatalf("Expected 1 error for Password, got %v", paramErrors)
+		}
+		if !strings.Contains(paramErrors[0].Err.Error(), missingFile) {
+			t.Errorf("Expected error to name the path, got %v", paramErrors[0].Err)
+		}
+	})
+}
+
+func TestStdin(t *testing.T) {
+
+	type Config struct {
+		Input []byte `flag:"input" stdin:"true"`
+	}
+
+	stdin := bytes.NewBufferString("body\nfrom stdin")
+	cfg := &Config{}
+	if err := ParseCombined(reflect.ValueOf(cfg), []string{"--input", "-"}, WithStdin(stdin)); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if string(cfg.Input) != "body\nfrom stdin" {
+		t.Errorf("Expected stdin body, got %q", cfg.Input)
+	}
+
+	type TwoStdin struct {
+		Input  string `flag:"input" stdin:"true"`
+		Input2 string `flag:"input2" stdin:"true"`
+	}
+
+	err := ParseCombined(reflect.ValueOf(&TwoStdin{}), []string{"--input", "-"}, WithStdin(stdin))
+	if err == nil {
+		t.Errorf("Expected error for multiple stdin fields")
+	}
+}
+
+func TestErrorsByField(t *testing.T) {
+
+	type Config struct {
+		Foo string `flag:"foo"`
+		Bar int    `flag:"bar"`
+	}
+
+	err := ParseCombined(reflect.ValueOf(&Config{}), []string{"--bar=nan", "--baz=1"})
+	paramErrors := ParamErrors{}
+	if !errors.As(err, &paramErrors) {
+		t.Fatalf("Expected ParamErrors, got %v", err)
+	}
+
+	byField := paramErrors.ErrorsByField()
+	if len(byField) != 3 {
+		t.Errorf("Expected 3 errors, got %v", byField)
+	}
+	for _, key := range []string{"Foo", "Bar", "--baz"} {
+		if byField[key] == nil {
+			t.Errorf("Expected error for %s", key)
+		}
+	}
+}
+
+func TestPrompt(t *testing.T) {
+
+	type Config struct {
+		Foo string `flag:"foo" description:"foo description"`
+		Bar string `flag:"bar" optional:"true"`
+		Baz string `flag:"baz" secret:"true"`
+	}
+
+	prompted := []PromptField{}
+	prompt := func(field PromptField) (string, bool) {
+		prompted = append(prompted, field)
+		if field.Secret {
+			return "", false
+		}
+		return "from-prompt", true
+	}
+
+	cfg := &Config{}
+	err := ParseCombined(reflect.ValueOf(cfg), []string{}, WithPrompt(prompt))
+	paramErrors := ParamErrors{}
+	if !errors.As(err, &paramErrors) {
+		t.Fatalf("Expected ParamErrors, got %v", err)
+	}
+	if len(paramErrors) != 1 || paramErrors[0].FieldName != "Baz" {
+		t.Errorf("Expected declined prompt to be required, got %v", paramErrors)
+	}
+
+	if cfg.Foo != "from-prompt" {
+		t.Errorf("Expected Foo from prompt, got %q", cfg.Foo)
+	}
+
+	if len(prompted) != 2 {
+		t.Fatalf("Expected 2 prompts for the required fields, got %v", prompted)
+	}
+	if prompted[0].Name != "--foo" || prompted[0].Description != "foo description" {
+		t.Errorf("Unexpected prompt %v", prompted[0])
+	}
+	if !prompted[1].Secret {
+		t.Errorf("Expected secret prompt for Baz")
+	}
+}
+
+func TestUnknownFlags(t *testing.T) {
+
+	type Config struct {
+		Foo     string   `flag:"foo"`
+		Forward []string `flag:",unknown"`
+	}
+
+	cfg := &Config{}
+	err := ParseCombined(reflect.ValueOf(cfg), []string{"--namespace", "prod", "--foo=foo", "-o=json", "--context", "dev"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if cfg.Foo != "foo" {
+		t.Errorf("Expected Foo to be parsed, got %q", cfg.Foo)
+	}
+
+	want := []string{"--namespace", "prod", "-o", "json", "--context", "dev"}
+	if !reflect.DeepEqual(cfg.Forward, want) {
+		t.Errorf("Expected %v, got %v", want, cfg.Forward)
+	}
+}
+
+func TestPointerFields(t *testing.T) {
+
+	type Config struct {
+		Count *int      `flag:"count" optional:"true"`
+		Debug *bool     `flag:"debug" optional:"true"`
+		Tags  *[]string `flag:"tags" optional:"true"`
+		Depth **int     `flag:"depth" optional:"true"`
+	}
+
+	t.Run("omitted", func(t *testing.T) {
+		cfg := &Config{}
+		if err := ParseCombined(reflect.ValueOf(cfg), []string{}); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if cfg.Count != nil || cfg.Debug != nil || cfg.Tags != nil || cfg.Depth != nil {
+			t.Errorf("Expected all fields to be nil, got %+v", cfg)
+		}
+	})
+
+	t.Run("zero values", func(t *testing.T) {
+		cfg := &Config{}
+		err := ParseCombined(reflect.ValueOf(cfg), []string{"--count=0", "--debug=false", "--tags="})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if cfg.Count == nil || *cfg.Count != 0 {
+			t.Errorf("Expected count to be allocated as 0, got %v", cfg.Count)
+		}
+		if cfg.Debug == nil || *cfg.Debug {
+			t.Errorf("Expected debug to be allocated as false, got %v", cfg.Debug)
+		}
+		if cfg.Tags == nil || len(*cfg.Tags) != 0 {
+			t.Errorf("Expected tags to be allocated and empty, got %v", cfg.Tags)
+		}
+	})
+
+	t.Run("provided", func(t *testing.T) {
+		cfg := &Config{}
+		err := ParseCombined(reflect.ValueOf(cfg), []string{"--count=3", "--debug", "--tags=a,b", "--depth=2"})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if cfg.Count == nil || *cfg.Count != 3 {
+			t.Errorf("Expected count 3, got %v", cfg.Count)
+		}
+		if cfg.Debug == nil || !*cfg.Debug {
+			t.Errorf("Expected debug true, got %v", cfg.Debug)
+		}
+		if cfg.Tags == nil || !reflect.DeepEqual(*cfg.Tags, []string{"a", "b"}) {
+			t.Errorf("Expected tags [a b], got %v", cfg.Tags)
+		}
+		if cfg.Depth == nil || *cfg.Depth == nil || **cfg.Depth != 2 {
+			t.Errorf("Expected depth 2, got %v", cfg.Depth)
+		}
+	})
+}
+
+func TestParseResultProvided(t *testing.T) {
+
+	t.Setenv("BAR", "")
+
+	cfg := &TestConfig{}
+	result, err := ParseWithResult(reflect.ValueOf(cfg), []string{"--foo=foo", "--n2", "true", "arg"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	for name, want := range map[string]bool{
+		"Foo":     true,
+		"Bar":     true, // set by env, even though empty
+		"Baz":     false,
+		"Arg":     true,
+		"N1":      false, // embedded, so not prefixed
+		"N2":      true,
+		"Missing": false,
+	} {
+		if got := result.Provided(name); got != want {
+			t.Errorf("Provided(%q): expected %v, got %v", name, want, got)
+		}
+	}
+
+	os.Unsetenv("BAR")
+	result, err = ParseWithResult(reflect.ValueOf(&TestConfig{}), []string{"--foo=foo"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if result.Provided("Bar") {
+		t.Errorf("Expected default value to not be provided")
+	}
+}
+
+func TestParseResultOrigin(t *testing.T) {
+
+	type Config struct {
+		Flag    string `flag:"flag" env:"ORIGIN_TEST_FLAG"`
+		Env     string `flag:"env" env:"ORIGIN_TEST_ENV"`
+		Source  string `flag:"source"`
+		File    string `flag:"file" default:"ignored"`
+		Default string `flag:"default" default:"d"`
+		Prompt  string `flag:"prompt"`
+		Verbose bool   `flag:"verbose"`
+		Unset   string `flag:"unset" optional:"true"`
+		Arg     string `flag:",arg0"`
+	}
+
+	t.Setenv("ORIGIN_TEST_FLAG", "env loses to flag")
+	t.Setenv("ORIGIN_TEST_ENV", "e")
+
+	configFilename := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(configFilename, []byte("file: f\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	prompt := func(PromptField) (string, bool) {
+		return "p", true
+	}
+
+	result, err := ParseWithResult(reflect.ValueOf(&Config{}),
+		[]string{"--flag=x", "--config", configFilename, "a"},
+		WithValueSources(MapSource{"source": "s"}),
+		WithPrompt(prompt),
+	)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	for name, want := range map[string]Origin{
+		"Flag":    OriginFlag,
+		"Env":     OriginEnv,
+		"Source":  OriginSource,
+		"File":    OriginConfigFile,
+		"Default": OriginDefault,
+		"Prompt":  OriginPrompt,
+		"Verbose": OriginDefault,
+		"Unset":   "",
+		"Arg":     OriginArg,
+	} {
+		if got := result.Origin(name); got != want {
+			t.Errorf("Origin(%q): expected %q, got %q", name, want, got)
+		}
+	}
+}
+
+func TestArgRange(t *testing.T) {
+
+	type Config struct {
+		Dest    string   `flag:",arg0"`
+		Sources []string `flag:",arg1-" optional:"true"`
+	}
+
+	for _, tc := range []struct {
+		name    string
+		args    []string
+		dest    string
+		sources []string
+	}{{
+		name:    "several",
+		args:    []string{"dst", "a", "b", "c"},
+		dest:    "dst",
+		sources: []string{"a", "b", "c"},
+	}, {
+		name:    "one",
+		args:    []string{"dst", "a,b"},
+		dest:    "dst",
+		sources: []string{"a,b"},
+	}, {
+		name: "none",
+		args: []string{"dst"},
+		dest: "dst",
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := &Config{}
+			if err := ParseCombined(reflect.ValueOf(cfg), tc.args); err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if cfg.Dest != tc.dest {
+				t.Errorf("Expected dest %q, got %q", tc.dest, cfg.Dest)
+			}
+			if !reflect.DeepEqual(cfg.Sources, tc.sources) {
+				t.Errorf("Expected sources %v, got %v", tc.sources, cfg.Sources)
+			}
+		})
+	}
+
+	t.Run("typed items", func(t *testing.T) {
+		cfg := &struct {
+			Ports []int `flag:",arg0-"`
+		}{}
+		err := ParseCombined(reflect.ValueOf(cfg), []string{"80", "x"})
+		if err == nil {
+			t.Fatalf("Expected error")
+		}
+		if !strings.Contains(err.Error(), "item 1") {
+			t.Errorf("Expected error to name the item, got %v", err)
+		}
+	})
+
+	t.Run("not a slice", func(t *testing.T) {
+		cfg := &struct {
+			Rest string `flag:",arg0-"`
+		}{}
+		if err := ParseCombined(reflect.ValueOf(cfg), []string{"a"}); err == nil {
+			t.Fatalf("Expected error")
+		}
+	})
+}
+
+func TestArgIndexes(t *testing.T) {
+
+	for _, tc := range []struct {
+		name   string
+		config interface{}
+		errMsg string
+	}{{
+		name: "gap",
+		config: &struct {
+			A string `flag:",arg0"`
+			C string `flag:",arg2"`
+		}{},
+		errMsg: "arg1 is missing",
+	}, {
+		name: "not from zero",
+		config: &struct {
+			B string `flag:",arg1"`
+		}{},
+		errMsg: "arg0 is missing",
+	}, {
+		name: "duplicate",
+		config: &struct {
+			A string `flag:",arg0"`
+			B string `flag:",arg0"`
+		}{},
+		errMsg: "arg0 is used by both A and B",
+	}, {
+		name: "range overlaps",
+		config: &struct {
+			A    string   `flag:",arg0"`
+			B    string   `flag:",arg1"`
+			Rest []string `flag:",arg1-"`
+		}{},
+		errMsg: "must start at arg2",
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			err := ParseCombined(reflect.ValueOf(tc.config), []string{"a", "b", "c"})
+			if err == nil {
+				t.Fatalf("Expected error")
+			}
+			if !strings.Contains(err.Error(), tc.errMsg) {
+				t.Errorf("Expected error containing %q, got %v", tc.errMsg, err)
+			}
+		})
+	}
+}
+
+func TestRequiredArgs(t *testing.T) {
+
+	type Config struct {
+		Src  string   `flag:",arg0"`
+		Dest string   `flag:",arg1" optional:"true"`
+		Rest []string `flag:",arg2-"`
+	}
+
+	err := ParseCombined(reflect.ValueOf(&Config{}), []string{})
+	paramErrors := ParamErrors{}
+	if !errors.As(err, &paramErrors) {
+		t.Fatalf("Expected ParamErrors, got %v", err)
+	}
+	if len(paramErrors) != 2 {
+		t.Fatalf("Expected 2 errors, got %v", paramErrors)
+	}
+
+	for idx, want := range []struct {
+		argN      int
+		fieldName string
+	}{{
+		argN:      0,
+		fieldName: "Src",
+	}, {
+		argN:      2,
+		fieldName: "Rest",
+	}} {
+		got := paramErrors[idx]
+		if got.ArgN == nil || *got.ArgN != want.argN {
+			t.Errorf("Expected error %d for arg%d, got %v", idx, want.argN, got.ArgN)
+		}
+		if got.FieldName != want.fieldName || !strings.HasPrefix(got.Err.Error(), "required") {
+			t.Errorf("Expected %s to be required, got %v", want.fieldName, got)
+		}
+	}
+
+	if err := ParseCombined(reflect.ValueOf(&Config{}), []string{"a", "b", "c"}); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+}
+
+func TestPositionalCounts(t *testing.T) {
+
+	type Config struct {
+		Src  string `flag:",arg0"`
+		Dest string `flag:",arg1"`
+	}
+
+	t.Run("too few", func(t *testing.T) {
+		err := ParseCombined(reflect.ValueOf(&Config{}), []string{"a"})
+		paramErrors := ParamErrors{}
+		if !errors.As(err, &paramErrors) {
+			t.Fatalf("Expected ParamErrors, got %v", err)
+		}
+		if len(paramErrors) != 1 {
+			t.Fatalf("Expected 1 error, got %v", paramErrors)
+		}
+		if paramErrors[0].ArgN == nil || *paramErrors[0].ArgN != 1 {
+			t.Errorf("Expected error for arg1, got %v", paramErrors[0].ArgN)
+		}
+		want := "required, got 1 of 2 required args"
+		if got := paramErrors[0].Err.Error(); got != want {
+			t.Errorf("Expected %q, got %q", want, got)
+		}
+	})
+
+	t.Run("too many", func(t *testing.T) {
+		err := ParseCombined(reflect.ValueOf(&Config{}), []string{"a", "b", "c", "d"})
+		paramErrors := ParamErrors{}
+		if !errors.As(err, &paramErrors) {
+			t.Fatalf("Expected ParamErrors, got %v", err)
+		}
+		if len(paramErrors) != 1 {
+			t.Fatalf("Expected 1 error, got %v", paramErrors)
+		}
+		want := `too many args, expected at most 2, got 4, unexpected ["c" "d"]`
+		if got := paramErrors[0].Err.Error(); got != want {
+			t.Errorf("Expected %q, got %q", want, got)
+		}
+	})
+
+	t.Run("remaining", func(t *testing.T) {
+		cfg := &struct {
+			Name string   `flag:",arg0"`
+			Rest []string `flag:",remaining"`
+		}{}
+		if err := ParseCombined(reflect.ValueOf(cfg), []string{"a", "b", "c"}); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if cfg.Name != "a" || !reflect.DeepEqual(cfg.Rest, []string{"b", "c"}) {
+			t.Errorf("Unexpected config %+v", cfg)
+		}
+	})
+}
+
+func TestCaseInsensitiveEnv(t *testing.T) {
+
+	type Config struct {
+		Foo string `env:"CASE_TEST_FOO" optional:"true"`
+	}
+
+	t.Setenv("case_test_foo", "lower")
+
+	cfg := &Config{}
+	if err := ParseCombined(reflect.ValueOf(cfg), []string{}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if cfg.Foo != "" {
+		t.Errorf("Expected exact matching by default, got %q", cfg.Foo)
+	}
+
+	cfg = &Config{}
+	if err := ParseCombined(reflect.ValueOf(cfg), []string{}, WithCaseInsensitiveEnv()); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if cfg.Foo != "lower" {
+		t.Errorf("Expected 'lower', got %q", cfg.Foo)
+	}
+
+	t.Setenv("CASE_TEST_FOO", "exact")
+	cfg = &Config{}
+	if err := ParseCombined(reflect.ValueOf(cfg), []string{}, WithCaseInsensitiveEnv()); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if cfg.Foo != "exact" {
+		t.Errorf("Expected exact match to win, got %q", cfg.Foo)
+	}
+}
+
+func TestEnvAliases(t *testing.T) {
+
+	type Config struct {
+		URL string `env:"ALIAS_TEST_DATABASE_URL, ALIAS_TEST_DB_URL,ALIAS_TEST_PGURL"`
+	}
+
+	t.Setenv("ALIAS_TEST_DB_URL", "second")
+	t.Setenv("ALIAS_TEST_PGURL", "third")
+
+	cfg := &Config{}
+	if err := ParseCombined(reflect.ValueOf(cfg), []string{}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if cfg.URL != "second" {
+		t.Errorf("Expected the first set alternative, got %q", cfg.URL)
+	}
+
+	t.Setenv("ALIAS_TEST_DATABASE_URL", "first")
+	cfg = &Config{}
+	if err := ParseCombined(reflect.ValueOf(cfg), []string{}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if cfg.URL != "first" {
+		t.Errorf("Expected the primary name to win, got %q", cfg.URL)
+	}
+
+	lines := GetHelpLines(reflect.TypeOf(Config{}))
+	if lines[0].EnvName != "ALIAS_TEST_DATABASE_URL" {
+		t.Errorf("Expected help to show the primary name, got %q", lines[0].EnvName)
+	}
+
+	t.Run("missing", func(t *testing.T) {
+		type Missing struct {
+			URL string `env:"ALIAS_TEST_UNSET_A,ALIAS_TEST_UNSET_B"`
+		}
+		err := ParseCombined(reflect.ValueOf(&Missing{}), []string{})
+		paramErrs := ParamErrors{}
+		if !errors.As(err, &paramErrs) || len(paramErrs) != 1 || paramErrs[0].Env != "ALIAS_TEST_UNSET_A" {
+			t.Errorf("Expected a required error for the primary name, got %v", err)
+		}
+	})
+
+	t.Run("empty name", func(t *testing.T) {
+		type Bad struct {
+			URL string `env:"ALIAS_TEST_A,,ALIAS_TEST_B"`
+		}
+		err := ParseCombined(reflect.ValueOf(&Bad{}), []string{})
+		if err == nil || !strings.Contains(err.Error(), "empty name in env tag") {
+			t.Errorf("Expected tag error, got %v", err)
+		}
+	})
+}
+
+type shade string
+
+func (s *shade) FromRunnerString(val string) error {
+	if strings.HasPrefix(val, "dark") {
+		return errors.New("too dark")
+	}
+	*s = shade(val)
+	return nil
+}
+
+func (s shade) EnumValues() []string {
+	return []string{"light", "dark-grey"}
+}
+
+func TestEnumValidation(t *testing.T) {
+
+	type Config struct {
+		Shade shade `flag:"shade"`
+	}
+
+	cfg := &Config{}
+	if err := ParseCombined(reflect.ValueOf(cfg), []string{"--shade=light"}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if cfg.Shade != "light" {
+		t.Errorf("Expected 'light', got %q", cfg.Shade)
+	}
+
+	err := ParseCombined(reflect.ValueOf(&Config{}), []string{"--shade=purple"})
+	fieldErr := ParamErrors{}
+	if !errors.As(err, &fieldErr) || len(fieldErr) != 1 {
+		t.Fatalf("Expected one ParamError, got %v", err)
+	}
+	want := `"purple" is not one of: light, dark-grey`
+	if got := fieldErr[0].Err.Error(); got != want {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+
+	// the setter's own validation still applies to listed values
+	err = ParseCombined(reflect.ValueOf(&Config{}), []string{"--shade=dark-grey"})
+	if err == nil || !strings.Contains(err.Error(), "too dark") {
+		t.Errorf("Expected setter error, got %v", err)
+	}
+}
+
+func TestFlagPrefixes(t *testing.T) {
+
+	type Config struct {
+		Verbose bool   `flag:"verbose"`
+		Version string `flag:"version" optional:"true"`
+		Name    string `flag:"name" optional:"true"`
+	}
+
+	for _, tc := range []struct {
+		name    string
+		args    []string
+		want    Config
+		wantErr string
+	}{{
+		name: "unambiguous",
+		args: []string{"--verb", "--na=app"},
+		want: Config{Verbose: true, Name: "app"},
+	}, {
+		name: "exact",
+		args: []string{"--version", "1.0"},
+		want: Config{Version: "1.0"},
+	}, {
+		name:    "ambiguous",
+		args:    []string{"--ver", "1.0"},
+		wantErr: "ambiguous flag, could be --verbose, --version",
+	}, {
+		name:    "no match",
+		args:    []string{"--other", "x"},
+		wantErr: "unknown flag",
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := Config{}
+			err := ParseCombined(reflect.ValueOf(&cfg), tc.args, WithFlagPrefixes())
+			if tc.wantErr != "" {
+				paramErrors := ParamErrors{}
+				if !errors.As(err, &paramErrors) || len(paramErrors) != 1 {
+					t.Fatalf("Expected one ParamError, got %v", err)
+				}
+				if got := paramErrors[0].Err.Error(); got != tc.wantErr {
+					t.Errorf("Expected %q, got %q", tc.wantErr, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if cfg != tc.want {
+				t.Errorf("Expected %+v, got %+v", tc.want, cfg)
+			}
+		})
+	}
+
+	t.Run("off by default", func(t *testing.T) {
+		cfg := Config{}
+		err := ParseCombined(reflect.ValueOf(&cfg), []string{"--verb"})
+		if err == nil {
+			t.Error("Expected prefixes to be rejected without the option")
+		}
+	})
+}
+
+func TestPositionalShapes(t *testing.T) {
+
+	type Config struct {
+		Name      string   `flag:"name" optional:"true"`
+		Target    string   `flag:",arg0"`
+		Remaining []string `flag:",remaining"`
+	}
+
+	for _, tc := range []struct {
+		name string
+		args []string
+		want Config
+	}{{
+		name: "equals",
+		args: []string{"--name", "app", "key=value", "a=b", "--c=d"},
+		want: Config{Name: "app", Target: "key=value", Remaining: []string{"a=b", "--c=d"}},
+	}, {
+		name: "after terminator",
+		args: []string{"--name=app", "--", "-target", "--name=other", "-"},
+		want: Config{Name: "app", Target: "-target", Remaining: []string{"--name=other", "-"}},
+	}, {
+		name: "terminator only",
+		args: []string{"--", "--", "x"},
+		want: Config{Target: "--", Remaining: []string{"x"}},
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := Config{}
+			if err := ParseCombined(reflect.ValueOf(&cfg), tc.args); err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if cfg.Name != tc.want.Name || cfg.Target != tc.want.Target || !slices.Equal(cfg.Remaining, tc.want.Remaining) {
+				t.Errorf("Expected %+v, got %+v", tc.want, cfg)
+			}
+		})
+	}
+}
+
+func TestExpandDefault(t *testing.T) {
+
+	type Config struct {
+		Expanded string `flag:"expanded" default:"${RUNNER_TEST_HOME}/.cache" expanddefault:"true"`
+		Literal  string `flag:"literal" default:"${RUNNER_TEST_HOME}/.cache"`
+		Given    string `flag:"given" default:"${RUNNER_TEST_HOME}" expanddefault:"true"`
+	}
+
+	t.Setenv("RUNNER_TEST_HOME", "/home/test")
+
+	cfg := Config{}
+	err := ParseCombined(reflect.ValueOf(&cfg), []string{"--given", "$NOT_EXPANDED"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if cfg.Expanded != "/home/test/.cache" {
+		t.Errorf("Expected expanded default, got %q", cfg.Expanded)
+	}
+	if cfg.Literal != "${RUNNER_TEST_HOME}/.cache" {
+		t.Errorf("Expected literal default, got %q", cfg.Literal)
+	}
+	if cfg.Given != "$NOT_EXPANDED" {
+		t.Errorf("Expected given value to be kept verbatim, got %q", cfg.Given)
+	}
+}
+
+func TestStructFlattening(t *testing.T) {
+
+	type Inner struct {
+		Host string `flag:"host" optional:"true"`
+	}
+
+	type Config struct {
+		Flat     Inner
+		Blob     Inner `flag:"blob" optional:"true" flatten:"false"`
+		Unprefix struct {
+			Port int `flag:"port" validate:"min=1"`
+		} `prefix:"false"`
+	}
+
+	cfg := Config{}
+	result, err := ParseWithResult(reflect.ValueOf(&cfg), []string{
+		"--host", "flat",
+		"--blob", `{"Host":"blob"}`,
+		"--port", "0",
+	})
+	paramErrors := ParamErrors{}
+	if !errors.As(err, &paramErrors) || len(paramErrors) != 1 {
+		t.Fatalf("Expected one ParamError, got %v", err)
+	}
+	if paramErrors[0].FieldName != "Port" {
+		t.Errorf("Expected unprefixed field name Port, got %q", paramErrors[0].FieldName)
+	}
+
+	if cfg.Flat.Host != "flat" {
+		t.Errorf("Expected flattened host, got %q", cfg.Flat.Host)
+	}
+	if cfg.Blob.Host != "blob" {
+		t.Errorf("Expected JSON host, got %q", cfg.Blob.Host)
+	}
+	if !result.Provided("Flat.Host") {
+		t.Errorf("Expected Flat.Host to be provided")
+	}
+
+	helpFlags := []string{}
+	for _, line := range GetHelpLines(reflect.TypeOf(Config{})) {
+		helpFlags = append(helpFlags, line.FlagName)
+	}
+	if want := []string{"host", "blob", "port"}; !slices.Equal(helpFlags, want) {
+		t.Errorf("Expected help flags %q, got %q", want, helpFlags)
+	}
+}
+
+func TestStructJSONErrors(t *testing.T) {
+
+	type Inner struct {
+		Host string
+		Port int
+	}
+
+	type Config struct {
+		Target Inner `flag:"target" flatten:"false"`
+		Secret Inner `flag:"secret" flatten:"false" secret:"true" optional:"true"`
+	}
+
+	for _, tc := range []struct {
+		name    string
+		args    []string
+		wantErr string
+	}{{
+		name:    "not an object",
+		args:    []string{"--target", "localhost:8080"},
+		wantErr: `struct field Target should be set using a JSON object, got "localhost:8080"`,
+	}, {
+		name:    "long value",
+		args:    []string{"--target", strings.Repeat("a", 50)},
+		wantErr: `struct field Target should be set using a JSON object, got "` + strings.Repeat("a", 40) + `"...`,
+	}, {
+		name:    "invalid JSON",
+		args:    []string{"--target", `{"Port":"80"}`},
+		wantErr: `struct field Target: invalid JSON "{\"Port\":\"80\"}": json: cannot unmarshal string into Go struct field Inner.Port of type int`,
+	}, {
+		name:    "secret",
+		args:    []string{"--target", "{}", "--secret", "hunter2"},
+		wantErr: "struct field Secret should be set using a JSON object, got ****",
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := Config{}
+			err := ParseCombined(reflect.ValueOf(&cfg), tc.args)
+			paramErrors := ParamErrors{}
+			if !errors.As(err, &paramErrors) || len(paramErrors) != 1 {
+				t.Fatalf("Expected one ParamError, got %v", err)
+			}
+			if got := paramErrors[0].Err.Error(); got != tc.wantErr {
+				t.Errorf("Expected %s\n     got %s", tc.wantErr, got)
+			}
+		})
+	}
+}
+
+func TestStructWholeOrFields(t *testing.T) {
+
+	type Database struct {
+		Host string `flag:"db-host" env:"RUNNER_TEST_DB_HOST" default:"localhost"`
+		Port int    `flag:"db-port" default:"5432"`
+	}
+
+	type Config struct {
+		Database Database `env:"RUNNER_TEST_DB"`
+	}
+
+	t.Run("fields", func(t *testing.T) {
+		t.Setenv("RUNNER_TEST_DB_HOST", "db.internal")
+		cfg := Config{}
+		if err := ParseCombined(reflect.ValueOf(&cfg), []string{"--db-port", "6543"}); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		want := Database{Host: "db.internal", Port: 6543}
+		if cfg.Database != want {
+			t.Errorf("Expected %+v, got %+v", want, cfg.Database)
+		}
+	})
+
+	t.Run("whole struct", func(t *testing.T) {
+		t.Setenv("RUNNER_TEST_DB", `{"Host":"json.internal","Port":1234}`)
+		t.Setenv("RUNNER_TEST_DB_HOST", "db.internal")
+		cfg := Config{}
+		if err := ParseCombined(reflect.ValueOf(&cfg), []string{}); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		want := Database{Host: "json.internal", Port: 1234}
+		if cfg.Database != want {
+			t.Errorf("Expected %+v, got %+v", want, cfg.Database)
+		}
+	})
+
+	t.Run("whole struct and field flag", func(t *testing.T) {
+		t.Setenv("RUNNER_TEST_DB", `{"Host":"json.internal"}`)
+		cfg := Config{}
+		err := ParseCombined(reflect.ValueOf(&cfg), []string{"--db-port", "6543"})
+		paramErrors := ParamErrors{}
+		if !errors.As(err, &paramErrors) || len(paramErrors) != 1 {
+			t.Fatalf("Expected one ParamError, got %v", err)
+		}
+		want := "can't be used when $RUNNER_TEST_DB sets the whole struct"
+		if got := paramErrors[0].Err.Error(); got != want {
+			t.Errorf("Expected %q, got %q", want, got)
+		}
+	})
+
+	t.Run("help", func(t *testing.T) {
+		lines := GetHelpLines(reflect.TypeOf(Config{}))
+		if len(lines) != 3 || lines[0].EnvName != "RUNNER_TEST_DB" || lines[0].Required {
+			t.Errorf("Expected optional struct line followed by its fields, got %+v", lines)
+		}
+	})
+}
+
+func TestEmbeddedConfig(t *testing.T) {
+
+	type BaseConfig struct {
+		Verbose bool   `flag:"verbose"`
+		Region  string `flag:"region" validate:"min=2"`
+	}
+
+	type Prefixed struct {
+		Zone string `flag:"zone" optional:"true"`
+	}
+
+	type DeployConfig struct {
+		*BaseConfig
+		Prefixed `prefix:"true"`
+		App      string `flag:"app"`
+	}
+
+	cfg := DeployConfig{}
+	result, err := ParseWithResult(reflect.ValueOf(&cfg), []string{"--verbose", "--region", "x", "--zone", "a", "--app", "api"})
+	paramErrors := ParamErrors{}
+	if !errors.As(err, &paramErrors) || len(paramErrors) != 1 {
+		t.Fatalf("Expected one ParamError, got %v", err)
+	}
+	if paramErrors[0].FieldName != "Region" {
+		t.Errorf("Expected embedded field name Region, got %q", paramErrors[0].FieldName)
+	}
+
+	if cfg.BaseConfig == nil {
+		t.Fatal("Expected pointer embed to be allocated")
+	}
+	if !cfg.Verbose || cfg.App != "api" || cfg.Zone != "a" {
+		t.Errorf("Unexpected config %+v %+v", cfg.BaseConfig, cfg)
+	}
+	for _, name := range []string{"Verbose", "Prefixed.Zone", "App"} {
+		if !result.Provided(name) {
+			t.Errorf("Expected %s to be provided", name)
+		}
+	}
+
+	helpFlags := []string{}
+	for _, line := range GetHelpLines(reflect.TypeOf(DeployConfig{})) {
+		helpFlags = append(helpFlags, line.FlagName)
+	}
+	if want := []string{"verbose", "region", "zone", "app"}; !slices.Equal(helpFlags, want) {
+		t.Errorf("Expected help flags %q, got %q", want, helpFlags)
+	}
+}
+
+func TestPassthrough(t *testing.T) {
+
+	type Config struct {
+		Verbose bool     `flag:"verbose"`
+		Target  string   `flag:",arg0"`
+		Args    []string `flag:",passthrough"`
+	}
+
+	for _, tc := range []struct {
+		name string
+		args []string
+		want Config
+	}{{
+		name: "flags after target",
+		args: []string{"proxy", "--flag", "value"},
+		want: Config{Target: "proxy", Args: []string{"--flag", "value"}},
+	}, {
+		name: "own flags first",
+		args: []string{"--verbose", "proxy", "--verbose", "-x"},
+		want: Config{Verbose: true, Target: "proxy", Args: []string{"--verbose", "-x"}},
+	}, {
+		name: "terminators",
+		args: []string{"--", "proxy", "--", "--flag"},
+		want: Config{Target: "proxy", Args: []string{"--", "--flag"}},
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := Config{}
+			if err := ParseCombined(reflect.ValueOf(&cfg), tc.args); err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if cfg.Verbose != tc.want.Verbose || cfg.Target != tc.want.Target || !slices.Equal(cfg.Args, tc.want.Args) {
+				t.Errorf("Expected %+v, got %+v", tc.want, cfg)
+			}
+		})
+	}
+
+	t.Run("with remaining", func(t *testing.T) {
+		type Both struct {
+			Args []string `flag:",passthrough"`
+			Rest []string `flag:",remaining"`
+		}
+		err := ParseCombined(reflect.ValueOf(&Both{}), []string{})
+		if err == nil || err.Error() != "only one field can be tagged with ,remaining or ,passthrough" {
+			t.Errorf("Expected error for both tags, got %v", err)
+		}
+	})
+}
+
+func TestArgFallback(t *testing.T) {
+
+	type Config struct {
+		File    string   `flag:"file" arg:"0" env:"CAT_FILE"`
+		Verbose bool     `flag:"verbose"`
+		Rest    []string `flag:",remaining"`
+	}
+
+	for _, tc := range []struct {
+		name      string
+		args      []string
+		env       map[string]string
+		want      Config
+		expectErr string
+	}{{
+		name: "flag",
+		args: []string{"--file", "x"},
+		want: Config{File: "x"},
+	}, {
+		name: "positional",
+		args: []string{"x"},
+		want: Config{File: "x"},
+	}, {
+		name: "positional after flags",
+		args: []string{"--verbose", "x", "y"},
+		want: Config{File: "x", Verbose: true, Rest: []string{"y"}},
+	}, {
+		name: "env",
+		env:  map[string]string{"CAT_FILE": "from-env"},
+		want: Config{File: "from-env"},
+	}, {
+		name: "positional over env",
+		args: []string{"x"},
+		env:  map[string]string{"CAT_FILE": "from-env"},
+		want: Config{File: "x"},
+	}, {
+		name:      "both",
+		args:      []string{"--file", "x", "y"},
+		expectErr: "given as both --file and arg0",
+	}, {
+		name:      "missing",
+		args:      []string{},
+		expectErr: "required",
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			for key, val := range tc.env {
+				t.Setenv(key, val)
+			}
+			cfg := Config{}
+			err := ParseCombined(reflect.ValueOf(&cfg), tc.args)
+			if tc.expectErr != "" {
+				paramErrs := ParamErrors{}
+				if !errors.As(err, &paramErrs) || len(paramErrs) != 1 {
+					t.Fatalf("Expected one ParamError, got %v", err)
+				}
+				if got := paramErrs[0].Err.Error(); got != tc.expectErr {
+					t.Errorf("Expected %q, got %q", tc.expectErr, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if cfg.File != tc.want.File || cfg.Verbose != tc.want.Verbose || !slices.Equal(cfg.Rest, tc.want.Rest) {
+				t.Errorf("Expected %+v, got %+v", tc.want, cfg)
+			}
+		})
+	}
+
+	t.Run("env", func(t *testing.T) {
+		type EnvArg struct {
+			Target string `flag:",arg0" env:"ARG_TEST_TARGET"`
+			Extra  string `flag:",arg1" optional:"true"`
+		}
+
+		t.Setenv("ARG_TEST_TARGET", "from-env")
+		cfg := EnvArg{}
+		if err := ParseCombined(reflect.ValueOf(&cfg), []string{}); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if cfg.Target != "from-env" {
+			t.Errorf("Expected env fallback, got %q", cfg.Target)
+		}
+
+		cfg = EnvArg{}
+		if err := ParseCombined(reflect.ValueOf(&cfg), []string{"given", "extra"}); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if cfg.Target != "given" || cfg.Extra != "extra" {
+			t.Errorf("Expected the positionals to win, got %+v", cfg)
+		}
+
+		os.Unsetenv("ARG_TEST_TARGET")
+		err := ParseCombined(reflect.ValueOf(&EnvArg{}), []string{})
+		paramErrs := ParamErrors{}
+		if !errors.As(err, &paramErrs) || len(paramErrs) != 1 || paramErrs[0].Env != "ARG_TEST_TARGET" {
+			t.Errorf("Expected a required error naming the env var, got %v", err)
+		}
+	})
+
+	t.Run("requires flag", func(t *testing.T) {
+		type Bad struct {
+			File string `flag:",arg0" arg:"0"`
+		}
+		err := ParseCombined(reflect.ValueOf(&Bad{}), []string{})
+		if err == nil || err.Error() != "field File: arg tag can't be used with ,arg0" {
+			t.Errorf("Expected tag error, got %v", err)
+		}
+	})
+}
+
+func TestIndexedEnv(t *testing.T) {
+
+	type Config struct {
+		Items []string `env:"INDEXED_TEST_ITEM" indexed:"true"`
+		Ports []int    `env:"INDEXED_TEST_PORT" indexed:"true" optional:"true"`
+	}
+
+	t.Setenv("INDEXED_TEST_ITEM_0", "a,b")
+	t.Setenv("INDEXED_TEST_ITEM_1", "c")
+	t.Setenv("INDEXED_TEST_ITEM_2", "d")
+	// after the gap, so not read
+	t.Setenv("INDEXED_TEST_ITEM_4", "e")
+
+	cfg := &Config{}
+	if err := ParseCombined(reflect.ValueOf(cfg), []string{}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if want := []string{"a,b", "c", "d"}; !slices.Equal(cfg.Items, want) {
+		t.Errorf("Expected %q, got %q", want, cfg.Items)
+	}
+	if cfg.Ports != nil {
+		t.Errorf("Expected no ports, got %v", cfg.Ports)
+	}
+
+	t.Run("plain env var", func(t *testing.T) {
+		t.Setenv("INDEXED_TEST_PORT", "80,443")
+		cfg := &Config{}
+		if err := ParseCombined(reflect.ValueOf(cfg), []string{}); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if want := []int{80, 443}; !slices.Equal(cfg.Ports, want) {
+			t.Errorf("Expected %v, got %v", want, cfg.Ports)
+		}
+	})
+
+	t.Run("invalid item", func(t *testing.T) {
+		t.Setenv("INDEXED_TEST_PORT_0", "80")
+		t.Setenv("INDEXED_TEST_PORT_1", "http")
+		err := ParseCombined(reflect.ValueOf(&Config{}), []string{})
+		paramErrs := ParamErrors{}
+		if !errors.As(err, &paramErrs) || len(paramErrs) != 1 || !strings.HasPrefix(paramErrs[0].Err.Error(), "item 1: ") {
+			t.Errorf("Expected an error for item 1, got %v", err)
+		}
+	})
+
+	t.Run("not a slice", func(t *testing.T) {
+		type Bad struct {
+			Item string `env:"INDEXED_TEST_BAD" indexed:"true"`
+		}
+		err := ParseCombined(reflect.ValueOf(&Bad{}), []string{})
+		if err == nil || err.Error() != "field Item: indexed can only be used on slice fields" {
+			t.Errorf("Expected tag error, got %v", err)
+		}
+	})
+}
+
+func TestPrefixedEnv(t *testing.T) {
+
+	type Config struct {
+		Labels map[string]string `env:"PREFIX_TEST_LABEL" envprefix:"true"`
+		Limits map[string]int    `env:"PREFIX_TEST_LIMIT" envprefix:"true" optional:"true"`
+	}
+
+	t.Setenv("PREFIX_TEST_LABEL_ENV", "prod")
+	t.Setenv("PREFIX_TEST_LABEL_TEAM", "core")
+	t.Setenv("PREFIX_TEST_LABEL", "not a label")
+	t.Setenv("PREFIX_TEST_LABELS_OTHER", "not a label either")
+
+	cfg := &Config{}
+	result, err := ParseWithResult(reflect.ValueOf(cfg), []string{})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	want := map[string]string{"env": "prod", "team": "core"}
+	if !reflect.DeepEqual(cfg.Labels, want) {
+		t.Errorf("Expected %v, got %v", want, cfg.Labels)
+	}
+	if cfg.Limits != nil {
+		t.Errorf("Expected no limits, got %v", cfg.Limits)
+	}
+	if result.Origin("Labels") != OriginEnv {
+		t.Errorf("Expected labels from env, got %q", result.Origin("Labels"))
+	}
+	if got := Describe(reflect.ValueOf(cfg))["Labels"]; got != "env=prod,team=core" {
+		t.Errorf("Expected labels to be described, got %q", got)
+	}
+
+	t.Run("typed values", func(t *testing.T) {
+		t.Setenv("PREFIX_TEST_LIMIT_CPU", "2")
+		t.Setenv("PREFIX_TEST_LIMIT_MEMORY", "lots")
+		err := ParseCombined(reflect.ValueOf(&Config{}), []string{})
+		paramErrs := ParamErrors{}
+		if !errors.As(err, &paramErrs) || len(paramErrs) != 1 || !strings.HasPrefix(paramErrs[0].Err.Error(), "key memory: ") {
+			t.Errorf("Expected an error for the memory key, got %v", err)
+		}
+	})
+
+	t.Run("string keys", func(t *testing.T) {
+		type Bad struct {
+			Ports map[int]string `env:"PREFIX_TEST_PORT" envprefix:"true"`
+		}
+		err := ParseCombined(reflect.ValueOf(&Bad{}), []string{})
+		if err == nil || err.Error() != "field Ports: envprefix can only be used on maps with string keys" {
+			t.Errorf("Expected tag error, got %v", err)
+		}
+	})
+
+	t.Run("env only", func(t *testing.T) {
+		type WithFlag struct {
+			Labels map[string]string `flag:"label" env:"PREFIX_TEST_LABEL" envprefix:"true"`
+		}
+		type WithDefault struct {
+			Labels map[string]string `env:"PREFIX_TEST_LABEL" envprefix:"true" default:"a=b"`
+		}
+		for _, rt := range []reflect.Type{reflect.TypeOf(WithFlag{}), reflect.TypeOf(WithDefault{})} {
+			want := "field Labels: envprefix can't be used with a flag or default"
+			err := ParseCombined(reflect.New(rt), []string{})
+			if err == nil || err.Error() != want {
+				t.Errorf("%s: Expected tag error, got %v", rt.Name(), err)
+			}
+			if errs := Lint(rt); len(errs) != 1 || errs[0].Error() != want {
+				t.Errorf("%s: Expected lint error, got %v", rt.Name(), errs)
+			}
+		}
+	})
+}
+
+type testVaultKey struct{}
+
+// vaultSecret looks its value up in a map carried by the context
+type vaultSecret string
+
+func (vs *vaultSecret) FromRunnerStringContext(ctx context.Context, path string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	vault, _ := ctx.Value(testVaultKey{}).(map[string]string)
+	secret, ok := vault[path]
+	if !ok {
+		return fmt.Errorf("no secret at %s", path)
+	}
+	*vs = vaultSecret(secret)
+	return nil
+}
+
+func (vs *vaultSecret) FromRunnerString(path string) error {
+	return errors.New("expected the context setter to be used")
+}
+
+func TestContextSetter(t *testing.T) {
+
+	type Config struct {
+		Password vaultSecret   `flag:"password"`
+		Keys     []vaultSecret `flag:"keys" optional:"true"`
+	}
+
+	vault := map[string]string{
+		"db/password": "hunter2",
+		"api/a":       "key-a",
+		"api/b":       "key-b",
+	}
+	ctx := context.WithValue(context.Background(), testVaultKey{}, vault)
+
+	cfg := &Config{}
+	err := ParseCombined(reflect.ValueOf(cfg), []string{"--password=db/password", "--keys=api/a,api/b"}, WithContext(ctx))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if cfg.Password != "hunter2" {
+		t.Errorf("Expected the password from the vault, got %q", cfg.Password)
+	}
+	if want := []vaultSecret{"key-a", "key-b"}; !slices.Equal(cfg.Keys, want) {
+		t.Errorf("Expected %q, got %q", want, cfg.Keys)
+	}
+
+	t.Run("canceled", func(t *testing.T) {
+		canceled, cancel := context.WithCancel(ctx)
+		cancel()
+		err := ParseCombined(reflect.ValueOf(&Config{}), []string{"--password=db/password"}, WithContext(canceled))
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("Expected context.Canceled, got %v", err)
+		}
+	})
+
+	t.Run("background", func(t *testing.T) {
+		var secret vaultSecret
+		err := SetFromString(&secret, "db/password")
+		if err == nil || err.Error() != "no secret at db/password" {
+			t.Errorf("Expected SetFromString to use a background context, got %v", err)
+		}
+	})
+}
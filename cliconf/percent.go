@@ -0,0 +1,47 @@
+package cliconf
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// parsePercent parses ratios for fields tagged `percent:"true"`. Values ending
+// in % are divided by 100, so 10% is 0.1, bare values such as 0.1 are used as
+// they are. Values over 100% aren't limited, e.g. 150% is 1.5, use the
+// validate tag to set a range.
+func parsePercent(stringVal string) (float64, error) {
+	trimmed := strings.TrimSpace(stringVal)
+	number, isPercent := strings.CutSuffix(trimmed, "%")
+	val, err := strconv.ParseFloat(strings.TrimSpace(number), 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid percentage %q", stringVal)
+	}
+	if isPercent {
+		val /= 100
+	}
+	return val, nil
+}
+
+func checkPercentTag(inputField reflect.StructField) error {
+	switch derefType(inputField.Type).Kind() {
+	case reflect.Float32, reflect.Float64:
+		return nil
+	}
+	return fmt.Errorf("field %s: percent can only be used on float fields", inputField.Name)
+}
+
+func setPercent(field *field, stringValue string) error {
+	val, err := parsePercent(stringValue)
+	if err != nil {
+		return err
+	}
+
+	fieldVal := allocValue(field.fieldVal)
+	if fieldVal.OverflowFloat(val) {
+		return fmt.Errorf("percentage %q is out of range for %s", stringValue, fieldVal.Type())
+	}
+	fieldVal.SetFloat(val)
+	return nil
+}
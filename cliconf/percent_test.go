@@ -0,0 +1,90 @@
+package cliconf
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParsePercent(t *testing.T) {
+
+	for _, tc := range []struct {
+		input     string
+		expected  float64
+		expectErr bool
+	}{
+		{input: "10%", expected: 0.1},
+		{input: "0.1", expected: 0.1},
+		{input: "12.5%", expected: 0.125},
+		{input: " 50 % ", expected: 0.5},
+		{input: "0%", expected: 0},
+		{input: "150%", expected: 1.5},
+		{input: "1", expected: 1},
+		{input: "-5%", expected: -0.05},
+		{input: "%", expectErr: true},
+		{input: "ten%", expectErr: true},
+		{input: "10%%", expectErr: true},
+		{input: "", expectErr: true},
+	} {
+		t.Run(tc.input, func(t *testing.T) {
+			got, err := parsePercent(tc.input)
+			if tc.expectErr {
+				if err == nil {
+					t.Fatalf("Expected error, got %v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if got != tc.expected {
+				t.Errorf("Expected %v, got %v", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestPercentTag(t *testing.T) {
+
+	type Config struct {
+		SampleRate float64  `flag:"sample-rate" percent:"true" default:"10%"`
+		Threshold  *float32 `flag:"threshold" percent:"true" optional:"true"`
+	}
+
+	cfg := &Config{}
+	if err := ParseCombined(reflect.ValueOf(cfg), []string{"--threshold=0.25"}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if cfg.SampleRate != 0.1 {
+		t.Errorf("Expected default of 0.1, got %v", cfg.SampleRate)
+	}
+	if cfg.Threshold == nil || *cfg.Threshold != 0.25 {
+		t.Errorf("Expected threshold of 0.25, got %v", cfg.Threshold)
+	}
+
+	if err := ParseCombined(reflect.ValueOf(&Config{}), []string{"--sample-rate=lots"}); err == nil {
+		t.Errorf("Expected an invalid percentage error")
+	}
+
+	t.Run("pointer to pointer", func(t *testing.T) {
+		type Nested struct {
+			Ratio **float64 `flag:"ratio" percent:"true"`
+		}
+		cfg := &Nested{}
+		if err := ParseCombined(reflect.ValueOf(cfg), []string{"--ratio=25%"}); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if cfg.Ratio == nil || *cfg.Ratio == nil || **cfg.Ratio != 0.25 {
+			t.Errorf("Expected ratio of 0.25, got %v", cfg.Ratio)
+		}
+	})
+
+	t.Run("not a float", func(t *testing.T) {
+		type Bad struct {
+			Rate int `flag:"rate" percent:"true"`
+		}
+		err := ParseCombined(reflect.ValueOf(&Bad{}), []string{})
+		if err == nil || err.Error() != "field Rate: percent can only be used on float fields" {
+			t.Errorf("Expected tag error, got %v", err)
+		}
+	})
+}
@@ -1,8 +1,10 @@
 package cliconf
 
 import (
+	"context"
 	"fmt"
 	"reflect"
+	"regexp"
 	"strconv"
 	"strings"
 	"time"
@@ -21,7 +23,20 @@ func toStructVal(rv reflect.Value) (reflect.Value, error) {
 	return rv, nil
 }
 
+// findStructFields lists the fields of the struct and its flattened structs,
+// allocating nil embedded pointers so that they can be set.
 func findStructFields(rv reflect.Value) ([]*field, error) {
+	return walkStructFields(rv, true)
+}
+
+// readStructFields is findStructFields without allocating, for reading the
+// fields of a config which is not being parsed. Nil embedded pointers are
+// skipped, along with their fields.
+func readStructFields(rv reflect.Value) ([]*field, error) {
+	return walkStructFields(rv, false)
+}
+
+func walkStructFields(rv reflect.Value, allocate bool) ([]*field, error) {
 	rt := rv.Type()
 
 	fields := make([]*field, 0)
@@ -37,45 +52,139 @@ func findStructFields(rv reflect.Value) ([]*field, error) {
 			fields = append(fields, parsed)
 		}
 
-		if fieldType.Type.Kind() != reflect.Struct {
+		if !flattenField(fieldType) {
 			continue
 		}
+		if !allocate && fieldValue.Kind() == reflect.Pointer {
+			if fieldValue.IsNil() {
+				continue
+			}
+			fieldValue = fieldValue.Elem()
+		}
 		subStruct, err := toStructVal(fieldValue)
 		if err != nil {
 			// INVERSION
 			continue
 		}
 
-		subFields, err := findStructFields(subStruct)
+		subFields, err := walkStructFields(subStruct, allocate)
 		if err != nil {
 			return nil, err
 		}
 		for _, subField := range subFields {
-			subField.fieldName = fieldType.Name + "." + subField.fieldName
+			subField.fieldName = fieldPrefix(fieldType) + subField.fieldName
+			if parsed != nil && subField.parent == nil {
+				subField.parent = parsed
+			}
 			fields = append(fields, subField)
 		}
+		if parsed != nil && len(subFields) > 0 {
+			// The subfields are the fallback when the struct isn't set as a
+			// whole
+			parsed.optional = true
+		}
 	}
 
 	return fields, nil
 }
 
+// flattenField is true for struct fields whose own fields are parsed as
+// flags, env vars etc, including embedded pointers to structs, which are
+// allocated when nil. Tag the struct `flatten:"false"` to stop the recursion,
+// so it is only set as a whole, from JSON.
+func flattenField(sf reflect.StructField) bool {
+	if strings.ToLower(sf.Tag.Get("flatten")) == "false" {
+		return false
+	}
+	if sf.Type.Kind() == reflect.Struct {
+		return true
+	}
+	return sf.Anonymous && sf.Type.Kind() == reflect.Pointer && sf.Type.Elem().Kind() == reflect.Struct
+}
+
+// fieldPrefix is prepended to the field names of a flattened struct, as used
+// in errors, ParseResult, required `when=` conditions and config file paths.
+// Flags and env vars are never prefixed. Embedded structs aren't prefixed,
+// matching how Go promotes their fields, e.g. a BaseConfig embedded in each
+// command's config contributes `Verbose` rather than `BaseConfig.Verbose`.
+// Tag a named struct `prefix:"false"` to drop its prefix too, or an embedded
+// one `prefix:"true"` to add it.
+func fieldPrefix(sf reflect.StructField) string {
+	prefix := !sf.Anonymous
+	if tagVal, ok := sf.Tag.Lookup("prefix"); ok {
+		prefix = strings.ToLower(tagVal) != "false"
+	}
+	if !prefix {
+		return ""
+	}
+	return sf.Name + "."
+}
+
 type field struct {
-	fieldName  string
-	isBool     bool
-	optional   bool
-	defaultVal *string
-	fieldVal   reflect.Value
+	fieldName   string
+	isBool      bool
+	isPointer   bool
+	optional    bool
+	defaultVal  *string
+	fieldVal    reflect.Value
+	validators  []validator
+	pattern     *regexp.Regexp
+	enum        []string
+	together    string
+	requiredIf  *fieldCondition
+	deprecated  string
+	secret      bool
+	description string
+	fromFile    bool
+	stdin       bool
+	encoding    string
+	size        bool
+	percent     bool
+	indexed     bool
+	envPrefix   bool
+
+	// expandDefault runs os.ExpandEnv over the default when it is used
+	expandDefault bool
+
+	// parent is set for the subfields of a struct which has its own flag or
+	// env tag. When the parent is provided as JSON, the subfields are skipped.
+	parent *field
+
+	// provided is set while parsing when the value was given as a flag, env
+	// var or arg, rather than falling back to a default.
+	provided bool
+
+	// origin is where the value came from while parsing, including defaults
+	origin Origin
+
+	// indexedItems are the values of indexed env vars, and prefixedItems
+	// those of prefixed env vars, set by popValue
+	indexedItems  []string
+	prefixedItems map[string]string
 
 	// one of the following
-	// - envName and/or flagName
+	// - envName and/or flagName, which may also have argN as a fallback
 	// - argN
-	// - remaining
+	// - remaining, which may also be passthrough
+	// - unknown
 
 	envName  string
 	flagName string
 
-	remaining bool
-	argn      *int
+	// envAliases are alternative env var names, checked in order after
+	// envName
+	envAliases []string
+
+	remaining   bool
+	passthrough bool
+	unknown     bool
+	argn        *int
+	argRange    bool
+
+	// argFallback is set for positional args which can also be given by a
+	// flag, from an `arg` tag, or by an env var. The positional arg is used
+	// when it is given.
+	argFallback bool
 }
 
 func structField(inputField reflect.StructField, val reflect.Value) (*field, error) {
@@ -89,45 +198,204 @@ func structField(inputField reflect.StructField, val reflect.Value) (*field, err
 	parts := strings.SplitN(flagName, ",", 2)
 	flagName = parts[0]
 	parsed := &field{
-		isBool:    inputField.Type.Kind() == reflect.Bool,
-		envName:   envName,
+		isBool:    derefType(inputField.Type).Kind() == reflect.Bool,
+		isPointer: inputField.Type.Kind() == reflect.Pointer,
 		flagName:  flagName,
 		fieldName: inputField.Name,
 		fieldVal:  val,
 	}
 
+	// `env:"DATABASE_URL,DB_URL"` tries each name in turn, the first is the
+	// one shown in help and errors
+	for idx, name := range strings.Split(envName, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			if envName != "" {
+				return nil, fmt.Errorf("field %s: empty name in env tag %q", inputField.Name, envName)
+			}
+			continue
+		}
+		if idx == 0 {
+			parsed.envName = name
+		} else {
+			parsed.envAliases = append(parsed.envAliases, name)
+		}
+	}
+
 	if len(parts) == 2 {
 		flagFlag := parts[1]
 
-		if flagFlag == "remaining" {
+		if flagFlag == "remaining" || flagFlag == "passthrough" {
 			if flagName != "" {
-				return nil, fmt.Errorf("param name %q cannot be used with ,remaining", flagName)
+				return nil, fmt.Errorf("param name %q cannot be used with ,%s", flagName, flagFlag)
 			}
 			if inputField.Type.Kind() != reflect.Slice {
-				return nil, fmt.Errorf("remaining args must be a slice")
+				return nil, fmt.Errorf("%s args must be a slice", flagFlag)
 			}
 			if inputField.Type.Elem().Kind() != reflect.String {
-				return nil, fmt.Errorf("remaining args must be a slice of strings")
+				return nil, fmt.Errorf("%s args must be a slice of strings", flagFlag)
 			}
+			// Only the args after those bound to ,argN fields are
+			// remaining, e.g. `cp <arg0> [remaining...]`. Flags are only
+			// parsed up to the first positional arg, so they are always
+			// verbatim, e.g. to pass on to a subprocess. A `--` before the
+			// first positional ends the flags and is dropped, a later `--`
+			// is kept. Passthrough behaves the same as remaining, but is
+			// shown as such in help.
 			parsed.remaining = true
+			parsed.passthrough = flagFlag == "passthrough"
+		} else if flagFlag == "unknown" {
+			if flagName != "" {
+				return nil, fmt.Errorf("param name %q cannot be used with ,unknown", flagName)
+			}
+			if inputField.Type.Kind() != reflect.Slice || inputField.Type.Elem().Kind() != reflect.String {
+				return nil, fmt.Errorf("unknown flags must be a slice of strings")
+			}
+			parsed.unknown = true
 		} else if strings.HasPrefix(flagFlag, "arg") {
 			if flagName != "" {
 				return nil, fmt.Errorf("param name %q cannot be used with ,argN", flagName)
 			}
-			argn, err := strconv.Atoi(strings.TrimPrefix(flagFlag, "arg"))
+			argStr := strings.TrimPrefix(flagFlag, "arg")
+			if strings.HasSuffix(argStr, "-") {
+				// argN- takes argN and every positional arg after it
+				if inputField.Type.Kind() != reflect.Slice {
+					return nil, fmt.Errorf("arg range %q must be a slice", flagFlag)
+				}
+				argStr = strings.TrimSuffix(argStr, "-")
+				parsed.argRange = true
+			}
+			argn, err := strconv.Atoi(argStr)
 			if err != nil {
 				return nil, fmt.Errorf("invalid arg number %q", flagFlag)
 			}
 			parsed.argn = &argn
+			// `flag:",arg0" env:"NAME"` reads $NAME when arg0 isn't given
+			parsed.argFallback = parsed.envName != "" && !parsed.argRange
+		}
+	}
+
+	if argTag, ok := tag.Lookup("arg"); ok {
+		// `flag:"file" arg:"0"` reads --file, or else arg0
+		if parsed.argn != nil || parsed.remaining || parsed.unknown {
+			return nil, fmt.Errorf("field %s: arg tag can't be used with ,%s", inputField.Name, parts[1])
+		}
+		if flagName == "" {
+			return nil, fmt.Errorf("field %s: arg tag requires a flag name", inputField.Name)
 		}
+		argn, err := strconv.Atoi(argTag)
+		if err != nil {
+			return nil, fmt.Errorf("field %s: invalid arg number %q", inputField.Name, argTag)
+		}
+		parsed.argn = &argn
+		parsed.argFallback = true
 	}
 
 	defaultStr, ok := tag.Lookup("default")
 	if ok {
 		parsed.defaultVal = &defaultStr
 	}
+	parsed.expandDefault = strings.ToLower(tag.Get("expanddefault")) == "true"
+
+	validators, err := parseValidateTag(tag.Get("validate"))
+	if err != nil {
+		return nil, fmt.Errorf("field %s: %w", inputField.Name, err)
+	}
+	parsed.validators = validators
 
-	if strings.ToLower(tag.Get("required")) == "false" {
+	if patternStr, ok := tag.Lookup("pattern"); ok {
+		kind := inputField.Type.Kind()
+		if kind == reflect.Pointer {
+			kind = inputField.Type.Elem().Kind()
+		}
+		if kind != reflect.String {
+			return nil, fmt.Errorf("field %s: pattern can only be used on string fields", inputField.Name)
+		}
+		pattern, err := regexp.Compile(patternStr)
+		if err != nil {
+			return nil, fmt.Errorf("field %s: invalid pattern: %w", inputField.Name, err)
+		}
+		parsed.pattern = pattern
+	}
+
+	parsed.enum = enumValues(inputField.Type)
+	parsed.together = tag.Get("together")
+	parsed.deprecated = tag.Get("deprecated")
+	parsed.secret = strings.ToLower(tag.Get("secret")) == "true"
+	parsed.description = tag.Get("description")
+	parsed.fromFile = strings.ToLower(tag.Get("fromfile")) == "true"
+
+	if encoding, ok := tag.Lookup("encoding"); ok {
+		if err := checkEncodingTag(inputField, encoding); err != nil {
+			return nil, err
+		}
+		parsed.encoding = encoding
+	}
+
+	if strings.ToLower(tag.Get("size")) == "true" {
+		if err := checkSizeTag(inputField); err != nil {
+			return nil, err
+		}
+		parsed.size = true
+	}
+
+	if strings.ToLower(tag.Get("indexed")) == "true" {
+		// `env:"ITEM" indexed:"true"` reads ITEM_0, ITEM_1 and so on
+		if parsed.envName == "" {
+			return nil, fmt.Errorf("field %s: indexed requires an env name", inputField.Name)
+		}
+		if inputField.Type.Kind() != reflect.Slice || inputField.Type.Elem().Kind() == reflect.Uint8 {
+			return nil, fmt.Errorf("field %s: indexed can only be used on slice fields", inputField.Name)
+		}
+		parsed.indexed = true
+	}
+
+	if strings.ToLower(tag.Get("envprefix")) == "true" {
+		// `env:"LABEL" envprefix:"true"` reads LABEL_ENV=prod as the key env
+		if inputField.Type.Kind() != reflect.Map || inputField.Type.Key().Kind() != reflect.String {
+			return nil, fmt.Errorf("field %s: envprefix can only be used on maps with string keys", inputField.Name)
+		}
+		if parsed.envName == "" {
+			return nil, fmt.Errorf("field %s: envprefix requires an env name", inputField.Name)
+		}
+		// Maps can't be parsed from a single string, so are only read from
+		// the prefixed env vars
+		if parsed.flagName != "" || parsed.defaultVal != nil {
+			return nil, fmt.Errorf("field %s: envprefix can't be used with a flag or default", inputField.Name)
+		}
+		parsed.envPrefix = true
+	}
+
+	if strings.ToLower(tag.Get("percent")) == "true" {
+		if err := checkPercentTag(inputField); err != nil {
+			return nil, err
+		}
+		parsed.percent = true
+	}
+
+	if strings.ToLower(tag.Get("stdin")) == "true" {
+		fieldType := inputField.Type
+		if fieldType.Kind() == reflect.Pointer {
+			fieldType = fieldType.Elem()
+		}
+		isString := fieldType.Kind() == reflect.String
+		isBytes := fieldType.Kind() == reflect.Slice && fieldType.Elem().Kind() == reflect.Uint8
+		if !isString && !isBytes {
+			return nil, fmt.Errorf("field %s: stdin can only be used on string or []byte fields", inputField.Name)
+		}
+		parsed.stdin = true
+	}
+
+	requiredTag := tag.Get("required")
+	if strings.HasPrefix(requiredTag, "when=") {
+		condition, err := parseFieldCondition(strings.TrimPrefix(requiredTag, "when="))
+		if err != nil {
+			return nil, fmt.Errorf("field %s: %w", inputField.Name, err)
+		}
+		// Conditional fields are optional until the condition is checked
+		parsed.requiredIf = condition
+		parsed.optional = true
+	} else if strings.ToLower(requiredTag) == "false" {
 		parsed.optional = true
 	} else if strings.ToLower(tag.Get("optional")) == "true" {
 		parsed.optional = true
@@ -137,15 +405,97 @@ func structField(inputField reflect.StructField, val reflect.Value) (*field, err
 
 }
 
+// provide marks the field as provided from the origin
+func (f *field) provide(origin Origin) {
+	f.provided = true
+	f.origin = origin
+}
+
+// providedParent returns the nearest enclosing struct field which was set as
+// a whole, or nil
+func (f *field) providedParent() *field {
+	for parent := f.parent; parent != nil; parent = parent.parent {
+		if parent.provided {
+			return parent
+		}
+	}
+	return nil
+}
+
+// required is true when the field fails to parse without a value. Fields with
+// a default, and plain booleans which default to false, always have a value.
+func (f *field) required() bool {
+	if f.optional || f.defaultVal != nil {
+		return false
+	}
+	return !f.isBool || f.isPointer
+}
+
+// EnumValuer is implemented by types which only accept a fixed set of values,
+// which are listed in help.
+type EnumValuer interface {
+	EnumValues() []string
+}
+
+// enumValues returns the values for enum types, or nil. A zero value of the
+// type is used, so EnumValues must not depend on the receiver's value.
+func enumValues(rt reflect.Type) []string {
+	enum, ok := reflect.New(derefType(rt)).Interface().(EnumValuer)
+	if !ok {
+		return nil
+	}
+	return enum.EnumValues()
+}
+
+// derefType returns the type underneath any levels of pointer
+func derefType(rt reflect.Type) reflect.Type {
+	for rt.Kind() == reflect.Pointer {
+		rt = rt.Elem()
+	}
+	return rt
+}
+
+// allocValue returns the value underneath any levels of pointer, allocating
+// nil pointers on the way, for setting a field's value
+func allocValue(fieldVal reflect.Value) reflect.Value {
+	for fieldVal.Kind() == reflect.Pointer {
+		if fieldVal.IsNil() {
+			fieldVal.Set(reflect.New(fieldVal.Type().Elem()))
+		}
+		fieldVal = fieldVal.Elem()
+	}
+	return fieldVal
+}
+
 // SetterFromEnv is used by SetFromString for custom types
 type SetterFromRunner interface {
 	FromRunnerString(string) error
 }
 
+// SetterFromRunnerContext is used by SetFromStringContext in preference to
+// SetterFromRunner, for custom types which need a context to set their value,
+// e.g. to fetch a secret from a vault. When parsing, the context is the one
+// passed to WithContext.
+type SetterFromRunnerContext interface {
+	FromRunnerStringContext(context.Context, string) error
+}
+
 // SetFromString attempts to translate a string to the given interface. Must be a pointer.
-// Standard Types string, bool, int, int(8-64) float(32, 64), time.Duration and []string.
+// Standard Types string, bool (true/false, yes/no, on/off, 1/0), int,
+// int(8-64) float(32, 64), time.Duration, time.Time (RFC3339), and comma
+// separated slices of any of these.
 // Custom types must have method FromEnvString(string) error
 func SetFromString(fieldInterface interface{}, stringVal string) error {
+	return SetFromStringContext(context.Background(), fieldInterface, stringVal)
+}
+
+// SetFromStringContext sets the value in the same way as SetFromString,
+// passing the context to types implementing SetterFromRunnerContext.
+func SetFromStringContext(ctx context.Context, fieldInterface interface{}, stringVal string) error {
+
+	if withSetter, ok := fieldInterface.(SetterFromRunnerContext); ok {
+		return withSetter.FromRunnerStringContext(ctx, stringVal)
+	}
 
 	if withSetter, ok := fieldInterface.(SetterFromRunner); ok {
 		return withSetter.FromRunnerString(stringVal)
@@ -158,7 +508,10 @@ func SetFromString(fieldInterface interface{}, stringVal string) error {
 		*field = stringVal
 		return nil
 	case *bool:
-		bVal := strings.HasPrefix(strings.ToLower(stringVal), "t")
+		bVal, err := parseBool(stringVal)
+		if err != nil {
+			return err
+		}
 		*field = bVal
 		return nil
 
@@ -167,41 +520,42 @@ func SetFromString(fieldInterface interface{}, stringVal string) error {
 		return nil
 
 	case *int:
-		*field, err = strconv.Atoi(stringVal)
+		field64, err := strconv.ParseInt(stringVal, intBase(stringVal), strconv.IntSize)
+		*field = int(field64)
 		return err
 	case *int64:
-		*field, err = strconv.ParseInt(stringVal, 10, 64)
+		*field, err = strconv.ParseInt(stringVal, intBase(stringVal), 64)
 		return err
 	case *int32:
-		field64, err := strconv.ParseInt(stringVal, 10, 32)
+		field64, err := strconv.ParseInt(stringVal, intBase(stringVal), 32)
 		*field = int32(field64)
 		return err
 	case *int16:
-		field64, err := strconv.ParseInt(stringVal, 10, 16)
+		field64, err := strconv.ParseInt(stringVal, intBase(stringVal), 16)
 		*field = int16(field64)
 		return err
 	case *int8:
-		field64, err := strconv.ParseInt(stringVal, 10, 8)
+		field64, err := strconv.ParseInt(stringVal, intBase(stringVal), 8)
 		*field = int8(field64)
 		return err
 
 	case *uint:
-		field64, err := strconv.ParseUint(stringVal, 10, 64)
+		field64, err := strconv.ParseUint(stringVal, intBase(stringVal), 64)
 		*field = uint(field64)
 		return err
 	case *uint64:
-		*field, err = strconv.ParseUint(stringVal, 10, 64)
+		*field, err = strconv.ParseUint(stringVal, intBase(stringVal), 64)
 		return err
 	case *uint32:
-		field64, err := strconv.ParseUint(stringVal, 10, 32)
+		field64, err := strconv.ParseUint(stringVal, intBase(stringVal), 32)
 		*field = uint32(field64)
 		return err
 	case *uint16:
-		field64, err := strconv.ParseUint(stringVal, 10, 16)
+		field64, err := strconv.ParseUint(stringVal, intBase(stringVal), 16)
 		*field = uint16(field64)
 		return err
 	case *uint8:
-		field64, err := strconv.ParseUint(stringVal, 10, 8)
+		field64, err := strconv.ParseUint(stringVal, intBase(stringVal), 8)
 		*field = uint8(field64)
 		return err
 
@@ -221,33 +575,178 @@ func SetFromString(fieldInterface interface{}, stringVal string) error {
 		*field = val
 		return nil
 
-	// TODO: Support an array of anything. Using reflect?
-	case *[]string:
-		vals := strings.Split(stringVal, ",")
-		out := make([]string, 0, len(vals))
-		for _, val := range vals {
-			stripped := strings.TrimSpace(val)
-			if stripped == "" {
-				continue
-			}
-			out = append(out, stripped)
+	case *time.Time:
+		val, err := time.Parse(time.RFC3339, stringVal)
+		if err != nil {
+			return err
 		}
-		*field = out
+		*field = val
+		return nil
+
+	case *[]string:
+		*field = splitList(stringVal)
 		return nil
 	}
 
+	// Other slices are split in the same way as []string, each item is then
+	// set as its element type
+	rv := reflect.ValueOf(fieldInterface)
+	if rv.Kind() == reflect.Pointer && rv.Elem().Kind() == reflect.Slice {
+		return setSliceFromString(ctx, rv.Elem(), stringVal)
+	}
+
 	return fmt.Errorf("unsupported type %T", fieldInterface)
 }
 
+// intBase is 0 for values with a 0x, 0o or 0b prefix, so strconv takes the
+// base from the prefix, otherwise 10. A leading 0 alone is still decimal.
+func intBase(stringVal string) int {
+	unsigned := strings.TrimLeft(stringVal, "+-")
+	if len(unsigned) > 2 && unsigned[0] == '0' {
+		switch unsigned[1] {
+		case 'x', 'X', 'o', 'O', 'b', 'B':
+			return 0
+		}
+	}
+	return 10
+}
+
+// parseBool accepts the values strconv.ParseBool does, along with yes, no, on
+// and off, case insensitively. Empty is false, e.g. an exported but empty env
+// var.
+func parseBool(stringVal string) (bool, error) {
+	switch strings.ToLower(strings.TrimSpace(stringVal)) {
+	case "1", "t", "true", "y", "yes", "on":
+		return true, nil
+	case "", "0", "f", "false", "n", "no", "off":
+		return false, nil
+	}
+	return false, fmt.Errorf("invalid boolean %q, expected true/false, yes/no, on/off or 1/0", stringVal)
+}
+
+func setSliceFromString(ctx context.Context, sliceVal reflect.Value, stringVal string) error {
+	return setSliceFromStrings(ctx, sliceVal, splitList(stringVal))
+}
+
+// setSliceFromStrings sets each item of the slice from the matching string,
+// as its element type
+func setSliceFromStrings(ctx context.Context, sliceVal reflect.Value, vals []string) error {
+	out := reflect.MakeSlice(sliceVal.Type(), len(vals), len(vals))
+	for idx, val := range vals {
+		if err := SetFromStringContext(ctx, out.Index(idx).Addr().Interface(), val); err != nil {
+			return fmt.Errorf("item %d: %w", idx, err)
+		}
+	}
+	sliceVal.Set(out)
+	return nil
+}
+
+func setMapFromStrings(ctx context.Context, mapVal reflect.Value, vals map[string]string) error {
+	out := reflect.MakeMapWithSize(mapVal.Type(), len(vals))
+	for key, val := range vals {
+		itemVal := reflect.New(mapVal.Type().Elem())
+		if err := SetFromStringContext(ctx, itemVal.Interface(), val); err != nil {
+			return fmt.Errorf("key %s: %w", key, err)
+		}
+		out.SetMapIndex(reflect.ValueOf(key).Convert(mapVal.Type().Key()), itemVal.Elem())
+	}
+	mapVal.Set(out)
+	return nil
+}
+
+// splitList splits a comma separated list, trimming space and skipping empty
+// entries
+func splitList(stringVal string) []string {
+	vals := strings.Split(stringVal, ",")
+	out := make([]string, 0, len(vals))
+	for _, val := range vals {
+		stripped := strings.TrimSpace(val)
+		if stripped == "" {
+			continue
+		}
+		out = append(out, stripped)
+	}
+	return out
+}
+
 type HelpLine struct {
 	FlagName  string
 	EnvName   string
 	ArgN      *int
 	Remaining bool
 
+	// ArgRange is set when ArgN is the first of a range of positional args
+	// which runs to the end of the args
+	ArgRange bool
+	Unknown  bool
+
 	Description string
 	Default     *string
 	Required    bool
+	Deprecated  string
+
+	// Placeholder names the value in usage, e.g. `--port <int>`. Taken from
+	// the `placeholder` tag, or derived from the field type. Empty for
+	// booleans.
+	Placeholder string
+
+	// Group is the help section for the field, from the `group` tag
+	Group string
+
+	// Secret fields should not have their values or defaults displayed
+	Secret bool
+
+	// Passthrough is set, along with Remaining, for `,passthrough` fields
+	Passthrough bool
+
+	// EnumValues lists the accepted values for types implementing EnumValuer
+	EnumValues []string
+
+	// IsBool flags take no value
+	IsBool bool
+}
+
+var (
+	durationType = reflect.TypeOf(time.Duration(0))
+	timeType     = reflect.TypeOf(time.Time{})
+)
+
+func defaultPlaceholder(rt reflect.Type) string {
+	rt = derefType(rt)
+
+	if reflect.PointerTo(rt).Implements(reflect.TypeOf((*SetterFromRunner)(nil)).Elem()) ||
+		reflect.PointerTo(rt).Implements(reflect.TypeOf((*SetterFromRunnerContext)(nil)).Elem()) {
+		return "<value>"
+	}
+
+	if rt == durationType {
+		return "<duration>"
+	}
+
+	if rt == timeType {
+		return "<time>"
+	}
+
+	switch rt.Kind() {
+	case reflect.Bool:
+		return ""
+	case reflect.String:
+		return "<string>"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return "<int>"
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "<uint>"
+	case reflect.Float32, reflect.Float64:
+		return "<float>"
+	case reflect.Struct:
+		return "<json>"
+	case reflect.Slice:
+		if rt.Elem().Kind() == reflect.Uint8 {
+			return "<string>"
+		}
+		return "<list>"
+	}
+	return "<value>"
 }
 
 func GetHelpLines(rt reflect.Type) []HelpLine {
@@ -258,24 +757,46 @@ func GetHelpLines(rt reflect.Type) []HelpLine {
 		if err != nil {
 			panic(err)
 		}
-		if tag == nil {
-			if field.Type.Kind() == reflect.Struct {
-				subLines := GetHelpLines(field.Type)
-				lines = append(lines, subLines...)
+		var subLines []HelpLine
+		if flattenField(field) {
+			subLines = GetHelpLines(derefType(field.Type))
+		}
+
+		if tag != nil {
+			if len(subLines) > 0 {
+				tag.optional = true
 			}
 
-			continue
+			placeholder, ok := field.Tag.Lookup("placeholder")
+			if !ok && tag.size {
+				placeholder = "<size>"
+			} else if !ok && tag.percent {
+				placeholder = "<percent>"
+			} else if !ok {
+				placeholder = defaultPlaceholder(field.Type)
+			}
+
+			lines = append(lines, HelpLine{
+				FlagName:    tag.flagName,
+				EnvName:     tag.envName,
+				Description: field.Tag.Get("description"),
+				Default:     tag.defaultVal,
+				Required:    tag.required(),
+				ArgN:        tag.argn,
+				ArgRange:    tag.argRange,
+				Remaining:   tag.remaining,
+				Passthrough: tag.passthrough,
+				Unknown:     tag.unknown,
+				Deprecated:  tag.deprecated,
+				Secret:      tag.secret,
+				Placeholder: placeholder,
+				Group:       field.Tag.Get("group"),
+				EnumValues:  tag.enum,
+				IsBool:      tag.isBool,
+			})
 		}
 
-		lines = append(lines, HelpLine{
-			FlagName:    tag.flagName,
-			EnvName:     tag.envName,
-			Description: field.Tag.Get("description"),
-			Default:     tag.defaultVal,
-			Required:    !tag.optional,
-			ArgN:        tag.argn,
-			Remaining:   tag.remaining,
-		})
+		lines = append(lines, subLines...)
 	}
 	return lines
 }
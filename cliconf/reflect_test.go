@@ -2,7 +2,9 @@ package cliconf
 
 import (
 	"reflect"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -69,9 +71,9 @@ func TestParseField(t *testing.T) {
 		assert.Equal(t, true, doo.optional)
 	}
 
-	n1, ok := byName["Nested.N1"]
+	n1, ok := byName["N1"]
 	if !ok {
-		t.Errorf("Expected 'Nested.N1' to be present")
+		t.Errorf("Expected embedded 'N1' to be present without a prefix")
 	} else {
 		assert.Equal(t, "n1", n1.flagName)
 	}
@@ -100,3 +102,251 @@ func TestSetFromString(t *testing.T) {
 		}
 	})
 }
+
+func TestSetFromStringTimes(t *testing.T) {
+
+	t.Run("durations", func(t *testing.T) {
+		val := []time.Duration{}
+		if err := SetFromString(&val, "1s, 2s,,5m"); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		want := []time.Duration{time.Second, 2 * time.Second, 5 * time.Minute}
+		if !reflect.DeepEqual(val, want) {
+			t.Errorf("Expected %v, got %v", want, val)
+		}
+	})
+
+	t.Run("invalid duration", func(t *testing.T) {
+		val := []time.Duration{}
+		err := SetFromString(&val, "1s,nope,5s")
+		if err == nil {
+			t.Fatalf("Expected error")
+		}
+		if !strings.HasPrefix(err.Error(), "item 1: ") {
+			t.Errorf("Expected error to name the index, got %v", err)
+		}
+	})
+
+	t.Run("times", func(t *testing.T) {
+		val := []time.Time{}
+		if err := SetFromString(&val, "2024-01-02T03:04:05Z,2024-02-03T04:05:06Z"); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if len(val) != 2 || !val[1].Equal(time.Date(2024, 2, 3, 4, 5, 6, 0, time.UTC)) {
+			t.Errorf("Unexpected times %v", val)
+		}
+	})
+}
+
+func TestParseTimeField(t *testing.T) {
+
+	type Config struct {
+		Since    time.Time       `flag:"since"`
+		Interval []time.Duration `flag:"retry-intervals"`
+	}
+
+	cfg := &Config{}
+	err := ParseCombined(reflect.ValueOf(cfg), []string{"--since=2024-01-02T03:04:05Z", "--retry-intervals=1s,2s"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !cfg.Since.Equal(time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)) {
+		t.Errorf("Unexpected time %v", cfg.Since)
+	}
+	if len(cfg.Interval) != 2 {
+		t.Errorf("Unexpected intervals %v", cfg.Interval)
+	}
+}
+
+type upperString string
+
+func (us *upperString) FromRunnerString(val string) error {
+	*us = upperString(strings.ToUpper(val))
+	return nil
+}
+
+func TestSetFromStringSlices(t *testing.T) {
+
+	t.Run("ints", func(t *testing.T) {
+		val := []int{}
+		if err := SetFromString(&val, "1, 2,3"); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		want := []int{1, 2, 3}
+		if !reflect.DeepEqual(val, want) {
+			t.Errorf("Expected %v, got %v", want, val)
+		}
+	})
+
+	t.Run("invalid int", func(t *testing.T) {
+		val := []int8{}
+		err := SetFromString(&val, "1,300")
+		if err == nil {
+			t.Fatalf("Expected error")
+		}
+		if !strings.HasPrefix(err.Error(), "item 1: ") {
+			t.Errorf("Expected error to name the index, got %v", err)
+		}
+	})
+
+	t.Run("setter elements", func(t *testing.T) {
+		val := []upperString{}
+		if err := SetFromString(&val, "a,b"); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		want := []upperString{"A", "B"}
+		if !reflect.DeepEqual(val, want) {
+			t.Errorf("Expected %v, got %v", want, val)
+		}
+	})
+
+	t.Run("unsupported elements", func(t *testing.T) {
+		val := []chan int{}
+		if err := SetFromString(&val, "a"); err == nil {
+			t.Fatalf("Expected error")
+		}
+	})
+}
+
+func TestSetFromStringBool(t *testing.T) {
+
+	for _, tc := range []struct {
+		input     string
+		expected  bool
+		expectErr bool
+	}{
+		{input: "true", expected: true},
+		{input: "TRUE", expected: true},
+		{input: "t", expected: true},
+		{input: "1", expected: true},
+		{input: "yes", expected: true},
+		{input: "Yes", expected: true},
+		{input: "y", expected: true},
+		{input: "on", expected: true},
+		{input: "ON", expected: true},
+		{input: "false", expected: false},
+		{input: "False", expected: false},
+		{input: "f", expected: false},
+		{input: "0", expected: false},
+		{input: "no", expected: false},
+		{input: "NO", expected: false},
+		{input: "n", expected: false},
+		{input: "off", expected: false},
+		{input: "Off", expected: false},
+		{input: "", expected: false},
+		{input: "maybe", expectErr: true},
+		{input: "2", expectErr: true},
+	} {
+		t.Run(tc.input, func(t *testing.T) {
+			val := !tc.expected
+			err := SetFromString(&val, tc.input)
+			if tc.expectErr {
+				if err == nil {
+					t.Fatalf("Expected error, got %v", val)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if val != tc.expected {
+				t.Errorf("Expected %v, got %v", tc.expected, val)
+			}
+		})
+	}
+}
+
+func TestSetFromStringIntBases(t *testing.T) {
+
+	for _, tc := range []struct {
+		input     string
+		expected  int64
+		expectErr bool
+	}{
+		{input: "255", expected: 255},
+		{input: "0255", expected: 255},
+		{input: "0xff", expected: 255},
+		{input: "0XFF", expected: 255},
+		{input: "0o17", expected: 15},
+		{input: "0b101", expected: 5},
+		{input: "-0x10", expected: -16},
+		{input: "+0b11", expected: 3},
+		{input: "0x", expectErr: true},
+		{input: "0b2", expectErr: true},
+		{input: "0x8000000000000000", expectErr: true},
+	} {
+		t.Run(tc.input, func(t *testing.T) {
+			var val int64
+			err := SetFromString(&val, tc.input)
+			if tc.expectErr {
+				if err == nil {
+					t.Fatalf("Expected error, got %v", val)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if val != tc.expected {
+				t.Errorf("Expected %v, got %v", tc.expected, val)
+			}
+		})
+	}
+
+	t.Run("sized", func(t *testing.T) {
+		var mask uint8
+		if err := SetFromString(&mask, "0xff"); err != nil || mask != 0xff {
+			t.Errorf("Expected 0xff, got %v, %v", mask, err)
+		}
+		if err := SetFromString(&mask, "0x100"); err == nil {
+			t.Errorf("Expected uint8 to overflow")
+		}
+
+		var small int8
+		if err := SetFromString(&small, "-0x80"); err != nil || small != -128 {
+			t.Errorf("Expected -128, got %v, %v", small, err)
+		}
+
+		var plain int
+		if err := SetFromString(&plain, "0o755"); err != nil || plain != 0o755 {
+			t.Errorf("Expected 0o755, got %v, %v", plain, err)
+		}
+
+		var unsigned uint
+		if err := SetFromString(&unsigned, "-0x1"); err == nil {
+			t.Errorf("Expected uint to reject a negative value")
+		}
+	})
+}
+
+type testEnum string
+
+func (te *testEnum) FromRunnerString(val string) error {
+	*te = testEnum(val)
+	return nil
+}
+
+func (te testEnum) EnumValues() []string {
+	return []string{"red", "green", "blue"}
+}
+
+func TestEnumHelpLines(t *testing.T) {
+
+	type Config struct {
+		Colour   testEnum  `flag:"colour"`
+		Optional *testEnum `flag:"optional" optional:"true"`
+		Plain    string    `flag:"plain"`
+	}
+
+	lines := GetHelpLines(reflect.TypeOf(Config{}))
+	want := []string{"red", "green", "blue"}
+	if !reflect.DeepEqual(lines[0].EnumValues, want) {
+		t.Errorf("Expected enum values %v, got %v", want, lines[0].EnumValues)
+	}
+	if !reflect.DeepEqual(lines[1].EnumValues, want) {
+		t.Errorf("Expected enum values for pointer %v, got %v", want, lines[1].EnumValues)
+	}
+	if lines[2].EnumValues != nil {
+		t.Errorf("Expected no enum values for plain string, got %v", lines[2].EnumValues)
+	}
+}
@@ -0,0 +1,147 @@
+package cliconf
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+func (f *field) displayName() string {
+	if f.flagName != "" {
+		return "--" + f.flagName
+	}
+	if f.envName != "" {
+		return "$" + f.envName
+	}
+	if f.argn != nil && f.argRange {
+		return fmt.Sprintf("<arg%d...>", *f.argn)
+	}
+	if f.argn != nil {
+		return fmt.Sprintf("<arg%d>", *f.argn)
+	}
+	return f.fieldName
+}
+
+func (f *field) promptField() PromptField {
+	return PromptField{
+		Name:        f.displayName(),
+		Description: f.description,
+		Secret:      f.secret,
+	}
+}
+
+// checkTogether enforces the `together` tag: all fields sharing a group name
+// must be provided, or none of them. Defaults do not count as provided, so
+// fields in a group should usually also be optional.
+func checkTogether(fields []*field) ParamErrors {
+	groupNames := make([]string, 0)
+	groups := map[string][]*field{}
+	for _, field := range fields {
+		if field.together == "" {
+			continue
+		}
+		if _, ok := groups[field.together]; !ok {
+			groupNames = append(groupNames, field.together)
+		}
+		groups[field.together] = append(groups[field.together], field)
+	}
+
+	errs := make(ParamErrors, 0)
+	for _, groupName := range groupNames {
+		group := groups[groupName]
+		provided := make([]string, 0, len(group))
+		missing := make([]*field, 0, len(group))
+		for _, field := range group {
+			if field.provided {
+				provided = append(provided, field.displayName())
+			} else {
+				missing = append(missing, field)
+			}
+		}
+
+		if len(provided) == 0 || len(missing) == 0 {
+			continue
+		}
+
+		for _, field := range missing {
+			errs = append(errs, ParamError{
+				Flag:      field.flagName,
+				Env:       field.envName,
+				FieldName: field.fieldName,
+				Err:       fmt.Errorf("required when %s is set", strings.Join(provided, ", ")),
+			})
+		}
+	}
+	return errs
+}
+
+// fieldCondition is a simple equality check against the value of another
+// field, referenced by its Go field name, e.g. `Mode==server`. Nested fields
+// are referenced with a dot, e.g. `Nested.Mode==server`.
+type fieldCondition struct {
+	fieldName string
+	value     string
+}
+
+func parseFieldCondition(condition string) (*fieldCondition, error) {
+	fieldName, value, ok := strings.Cut(condition, "==")
+	if !ok || fieldName == "" {
+		return nil, fmt.Errorf("invalid condition %q, expected Field==value", condition)
+	}
+	return &fieldCondition{
+		fieldName: strings.TrimSpace(fieldName),
+		value:     strings.TrimSpace(value),
+	}, nil
+}
+
+func (fc fieldCondition) String() string {
+	return fmt.Sprintf("%s is %s", fc.fieldName, fc.value)
+}
+
+// checkRequiredIf enforces `required:"when=Field==value"` tags. It runs after
+// every field has been set, so the condition field is always parsed first
+// regardless of the order of the fields in the struct. The condition compares
+// the string form of the other field's final value, including defaults. The
+// required field is satisfied when it was given at all, or has a default.
+func checkRequiredIf(fields []*field) (ParamErrors, error) {
+	byName := make(map[string]*field, len(fields))
+	for _, field := range fields {
+		byName[field.fieldName] = field
+	}
+
+	errs := make(ParamErrors, 0)
+	for _, field := range fields {
+		if field.requiredIf == nil {
+			continue
+		}
+		condField, ok := byName[field.requiredIf.fieldName]
+		if !ok {
+			return nil, fmt.Errorf("field %s: condition references unknown field %s", field.fieldName, field.requiredIf.fieldName)
+		}
+
+		condVal := condField.fieldVal
+		if condVal.Kind() == reflect.Pointer {
+			if condVal.IsNil() {
+				continue
+			}
+			condVal = condVal.Elem()
+		}
+		if fmt.Sprint(condVal.Interface()) != field.requiredIf.value {
+			continue
+		}
+
+		// Presence counts rather than the value, so that a zero value given
+		// explicitly, e.g. --retries=0, satisfies the condition
+		if field.provided || field.defaultVal != nil || field.providedParent() != nil {
+			continue
+		}
+
+		errs = append(errs, ParamError{
+			Flag:      field.flagName,
+			Env:       field.envName,
+			FieldName: field.fieldName,
+			Err:       fmt.Errorf("required when %s", field.requiredIf),
+		})
+	}
+	return errs, nil
+}
@@ -0,0 +1,121 @@
+package cliconf
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestTogether(t *testing.T) {
+
+	type Config struct {
+		Cert string `flag:"tls-cert" optional:"true" together:"tls"`
+		Key  string `flag:"tls-key" optional:"true" together:"tls"`
+	}
+
+	for _, tc := range []struct {
+		name    string
+		args    []string
+		wantErr string
+	}{{
+		name: "none",
+		args: []string{},
+	}, {
+		name: "all",
+		args: []string{"--tls-cert=cert.pem", "--tls-key=key.pem"},
+	}, {
+		name:    "partial",
+		args:    []string{"--tls-cert=cert.pem"},
+		wantErr: "required when --tls-cert is set",
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			err := ParseCombined(reflect.ValueOf(&Config{}), tc.args)
+			if tc.wantErr == "" {
+				if err != nil {
+					t.Errorf("Expected no error, got %v", err)
+				}
+				return
+			}
+
+			paramErrors := ParamErrors{}
+			if !errors.As(err, &paramErrors) {
+				t.Fatalf("Expected ParamErrors, got %v", err)
+			}
+			if len(paramErrors) != 1 {
+				t.Fatalf("Expected 1 error, got %v", paramErrors)
+			}
+			if paramErrors[0].Flag != "tls-key" {
+				t.Errorf("Expected error for tls-key, got %s", paramErrors[0].Flag)
+			}
+			if paramErrors[0].Err.Error() != tc.wantErr {
+				t.Errorf("Expected %q, got %q", tc.wantErr, paramErrors[0].Err)
+			}
+		})
+	}
+}
+
+func TestRequiredIf(t *testing.T) {
+
+	type Config struct {
+		// Addr is declared before Mode to check evaluation order
+		Addr string `flag:"addr" required:"when=Mode==server"`
+		Mode string `flag:"mode" default:"client"`
+	}
+
+	for _, tc := range []struct {
+		name    string
+		args    []string
+		wantErr bool
+	}{{
+		name: "condition not met",
+		args: []string{},
+	}, {
+		name: "condition met and set",
+		args: []string{"--mode=server", "--addr=:8080"},
+	}, {
+		name:    "condition met and missing",
+		args:    []string{"--mode=server"},
+		wantErr: true,
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			err := ParseCombined(reflect.ValueOf(&Config{}), tc.args)
+			if !tc.wantErr {
+				if err != nil {
+					t.Errorf("Expected no error, got %v", err)
+				}
+				return
+			}
+
+			paramErrors := ParamErrors{}
+			if !errors.As(err, &paramErrors) {
+				t.Fatalf("Expected ParamErrors, got %v", err)
+			}
+			if len(paramErrors) != 1 || paramErrors[0].Flag != "addr" {
+				t.Fatalf("Expected 1 error for addr, got %v", paramErrors)
+			}
+			if paramErrors[0].Err.Error() != "required when Mode is server" {
+				t.Errorf("Unexpected error %q", paramErrors[0].Err)
+			}
+		})
+	}
+
+	t.Run("zero values given", func(t *testing.T) {
+		type ZeroConfig struct {
+			Mode    string `flag:"mode"`
+			Retries int    `flag:"retries" required:"when=Mode==server"`
+			Verify  bool   `flag:"verify" required:"when=Mode==server"`
+			Prefix  string `flag:"prefix" required:"when=Mode==server"`
+		}
+
+		args := []string{"--mode=server", "--retries=0", "--verify=false", "--prefix="}
+		if err := ParseCombined(reflect.ValueOf(&ZeroConfig{}), args); err != nil {
+			t.Errorf("Expected no error, got %v", err)
+		}
+
+		err := ParseCombined(reflect.ValueOf(&ZeroConfig{}), []string{"--mode=server"})
+		paramErrors := ParamErrors{}
+		if !errors.As(err, &paramErrors) || len(paramErrors) != 3 {
+			t.Errorf("Expected 3 ParamErrors, got %v", err)
+		}
+	})
+}
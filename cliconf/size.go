@@ -0,0 +1,102 @@
+package cliconf
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// byteSizeUnits are the suffixes accepted by fields tagged `size:"true"`.
+// Following SI, K, M, G and T are powers of 1000, the binary Ki, Mi, Gi and Ti
+// are powers of 1024. Either may end in B, e.g. 10MB or 4KiB, and a plain
+// number, or one ending in B alone, is a number of bytes. Suffixes are case
+// insensitive.
+var byteSizeUnits = map[string]float64{
+	"":   1,
+	"k":  1e3,
+	"m":  1e6,
+	"g":  1e9,
+	"t":  1e12,
+	"ki": 1 << 10,
+	"mi": 1 << 20,
+	"gi": 1 << 30,
+	"ti": 1 << 40,
+}
+
+// parseByteSize parses sizes such as 10MB, 4KiB, 2G or 1.5GiB into a number
+// of bytes, which must be whole.
+func parseByteSize(stringVal string) (int64, error) {
+	trimmed := strings.TrimSpace(stringVal)
+	numberEnd := strings.IndexFunc(trimmed, func(r rune) bool {
+		return (r < '0' || r > '9') && r != '.'
+	})
+	if numberEnd == -1 {
+		numberEnd = len(trimmed)
+	}
+	number, suffix := trimmed[:numberEnd], strings.ToLower(strings.TrimSpace(trimmed[numberEnd:]))
+	if number == "" {
+		return 0, fmt.Errorf("invalid size %q", stringVal)
+	}
+
+	multiplier, ok := byteSizeUnits[strings.TrimSuffix(suffix, "b")]
+	if !ok {
+		return 0, fmt.Errorf("invalid size %q, unknown unit %q", stringVal, suffix)
+	}
+
+	if !strings.Contains(number, ".") {
+		val, err := strconv.ParseInt(number, 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid size %q: %w", stringVal, err)
+		}
+		if val > math.MaxInt64/int64(multiplier) {
+			return 0, fmt.Errorf("size %q is out of range", stringVal)
+		}
+		return val * int64(multiplier), nil
+	}
+
+	val, err := strconv.ParseFloat(number, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid size %q: %w", stringVal, err)
+	}
+	bytes := val * multiplier
+	if bytes >= math.MaxInt64 {
+		return 0, fmt.Errorf("size %q is out of range", stringVal)
+	}
+	if bytes != math.Trunc(bytes) {
+		return 0, fmt.Errorf("size %q is not a whole number of bytes", stringVal)
+	}
+	return int64(bytes), nil
+}
+
+func checkSizeTag(inputField reflect.StructField) error {
+	switch derefType(inputField.Type).Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return nil
+	}
+	return fmt.Errorf("field %s: size can only be used on integer fields", inputField.Name)
+}
+
+func setByteSize(field *field, stringValue string) error {
+	size, err := parseByteSize(stringValue)
+	if err != nil {
+		return err
+	}
+
+	fieldVal := allocValue(field.fieldVal)
+	switch fieldVal.Kind() {
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if fieldVal.OverflowUint(uint64(size)) {
+			return fmt.Errorf("size %q is out of range for %s", stringValue, fieldVal.Type())
+		}
+		fieldVal.SetUint(uint64(size))
+	default:
+		if fieldVal.OverflowInt(size) {
+			return fmt.Errorf("size %q is out of range for %s", stringValue, fieldVal.Type())
+		}
+		fieldVal.SetInt(size)
+	}
+	return nil
+}
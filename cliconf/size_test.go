@@ -0,0 +1,110 @@
+package cliconf
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseByteSize(t *testing.T) {
+
+	for _, tc := range []struct {
+		input     string
+		expected  int64
+		expectErr bool
+	}{
+		{input: "512", expected: 512},
+		{input: "512B", expected: 512},
+		{input: "1K", expected: 1000},
+		{input: "1KB", expected: 1000},
+		{input: "1kb", expected: 1000},
+		{input: "4KiB", expected: 4096},
+		{input: "4Ki", expected: 4096},
+		{input: "10MB", expected: 10_000_000},
+		{input: "10MiB", expected: 10 << 20},
+		{input: "2G", expected: 2_000_000_000},
+		{input: "2GB", expected: 2_000_000_000},
+		{input: "2GiB", expected: 2 << 30},
+		{input: "1TiB", expected: 1 << 40},
+		{input: "1.5GiB", expected: 3 << 29},
+		{input: "1.5KB", expected: 1500},
+		{input: " 8 MB ", expected: 8_000_000},
+		{input: "", expectErr: true},
+		{input: "MB", expectErr: true},
+		{input: "10XB", expectErr: true},
+		{input: "10PB", expectErr: true},
+		{input: "10Kb", expected: 10_000},
+		{input: "1.5B", expectErr: true},
+		{input: "1.2.3MB", expectErr: true},
+		{input: "-1MB", expectErr: true},
+		{input: "10000000TiB", expectErr: true},
+	} {
+		t.Run(tc.input, func(t *testing.T) {
+			got, err := parseByteSize(tc.input)
+			if tc.expectErr {
+				if err == nil {
+					t.Fatalf("Expected error, got %d", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if got != tc.expected {
+				t.Errorf("Expected %d, got %d", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestSizeTag(t *testing.T) {
+
+	type Config struct {
+		Buffer int64   `flag:"buffer" size:"true" default:"4KiB"`
+		Limit  *uint32 `flag:"limit" size:"true" optional:"true"`
+	}
+
+	cfg := &Config{}
+	if err := ParseCombined(reflect.ValueOf(cfg), []string{"--limit=10MB"}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if cfg.Buffer != 4096 {
+		t.Errorf("Expected default of 4096, got %d", cfg.Buffer)
+	}
+	if cfg.Limit == nil || *cfg.Limit != 10_000_000 {
+		t.Errorf("Expected limit of 10000000, got %v", cfg.Limit)
+	}
+
+	if err := ParseCombined(reflect.ValueOf(&Config{}), []string{"--limit=8GiB"}); err == nil {
+		t.Errorf("Expected uint32 to overflow")
+	}
+
+	t.Run("pointer to pointer", func(t *testing.T) {
+		type Nested struct {
+			Limit **int64 `flag:"limit" size:"true"`
+		}
+		cfg := &Nested{}
+		if err := ParseCombined(reflect.ValueOf(cfg), []string{"--limit=2KiB"}); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if cfg.Limit == nil || *cfg.Limit == nil || **cfg.Limit != 2048 {
+			t.Errorf("Expected limit of 2048, got %v", cfg.Limit)
+		}
+	})
+
+	t.Run("not an integer", func(t *testing.T) {
+		type Bad struct {
+			Size string `flag:"size" size:"true"`
+		}
+		err := ParseCombined(reflect.ValueOf(&Bad{}), []string{})
+		if err == nil || err.Error() != "field Size: size can only be used on integer fields" {
+			t.Errorf("Expected tag error, got %v", err)
+		}
+	})
+
+	t.Run("help", func(t *testing.T) {
+		lines := GetHelpLines(reflect.TypeOf(Config{}))
+		if lines[0].Placeholder != "<size>" {
+			t.Errorf("Expected <size> placeholder, got %q", lines[0].Placeholder)
+		}
+	})
+}
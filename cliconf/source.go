@@ -0,0 +1,25 @@
+package cliconf
+
+// ValueSource supplies values from a backend other than flags and env vars,
+// e.g. a config file or a key value store.
+//
+// Lookup is called with the field's flag name, or with the env var name for
+// fields which only have an env tag.
+type ValueSource interface {
+	Lookup(key string) (string, bool)
+}
+
+// MapSource is a ValueSource backed by a map
+type MapSource map[string]string
+
+func (ms MapSource) Lookup(key string) (string, bool) {
+	val, ok := ms[key]
+	return val, ok
+}
+
+func sourceKey(tag *field) string {
+	if tag.flagName != "" {
+		return tag.flagName
+	}
+	return tag.envName
+}
@@ -0,0 +1,43 @@
+package cliconf
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestValueSources(t *testing.T) {
+
+	type Config struct {
+		Foo string `flag:"foo" env:"FOO"`
+		Bar string `flag:"bar" env:"BAR" default:"bar"`
+		Baz string `env:"BAZ" default:"baz"`
+	}
+
+	t.Setenv("FOO", "env-foo")
+	t.Setenv("BAR", "env-bar")
+
+	first := MapSource{
+		"foo": "first-foo",
+	}
+	second := MapSource{
+		"foo": "second-foo",
+		"bar": "second-bar",
+		"BAZ": "second-baz",
+	}
+
+	cfg := &Config{}
+	err := ParseCombined(reflect.ValueOf(cfg), []string{"--bar=flag-bar"}, WithValueSources(first, second))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if cfg.Foo != "first-foo" {
+		t.Errorf("Foo: Expected first source to win over later sources and env, got %q", cfg.Foo)
+	}
+	if cfg.Bar != "flag-bar" {
+		t.Errorf("Bar: Expected flag to win over sources, got %q", cfg.Bar)
+	}
+	if cfg.Baz != "second-baz" {
+		t.Errorf("Baz: Expected env-only field to be looked up by env name, got %q", cfg.Baz)
+	}
+}
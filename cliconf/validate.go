@@ -0,0 +1,126 @@
+package cliconf
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// validator checks a parsed field value, returning a descriptive error if the
+// value is not valid.
+type validator func(reflect.Value) error
+
+// parseValidateTag parses the `validate` tag, a comma separated list of rules:
+// - min=N: numbers must be at least N, strings, slices and maps must have at least N elements
+// - max=N: as min, but at most N
+// - oneof=a b c: the value must be one of the space separated options
+// - nonempty: the value must not be the zero value, or have zero length
+func parseValidateTag(tag string) ([]validator, error) {
+	if tag == "" {
+		return nil, nil
+	}
+
+	validators := make([]validator, 0)
+	for _, rule := range strings.Split(tag, ",") {
+		rule = strings.TrimSpace(rule)
+		name, arg, _ := strings.Cut(rule, "=")
+		switch name {
+		case "min":
+			limit, err := strconv.ParseFloat(arg, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid min %q", arg)
+			}
+			validators = append(validators, func(rv reflect.Value) error {
+				if size, ok := validateSize(rv); ok && size < limit {
+					return fmt.Errorf("must be at least %s", arg)
+				}
+				return nil
+			})
+
+		case "max":
+			limit, err := strconv.ParseFloat(arg, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid max %q", arg)
+			}
+			validators = append(validators, func(rv reflect.Value) error {
+				if size, ok := validateSize(rv); ok && size > limit {
+					return fmt.Errorf("must be at most %s", arg)
+				}
+				return nil
+			})
+
+		case "oneof":
+			options := strings.Fields(arg)
+			if len(options) == 0 {
+				return nil, fmt.Errorf("oneof requires at least one option")
+			}
+			validators = append(validators, func(rv reflect.Value) error {
+				val := fmt.Sprint(rv.Interface())
+				for _, option := range options {
+					if val == option {
+						return nil
+					}
+				}
+				return fmt.Errorf("must be one of %s", strings.Join(options, ", "))
+			})
+
+		case "nonempty":
+			validators = append(validators, func(rv reflect.Value) error {
+				switch rv.Kind() {
+				case reflect.String, reflect.Slice, reflect.Map:
+					if rv.Len() == 0 {
+						return errors.New("must not be empty")
+					}
+				default:
+					if rv.IsZero() {
+						return errors.New("must not be empty")
+					}
+				}
+				return nil
+			})
+
+		default:
+			return nil, fmt.Errorf("unknown validation rule %q", name)
+		}
+	}
+	return validators, nil
+}
+
+// validateSize returns the number to compare against min and max: the value of
+// numbers, or the length of strings, slices and maps.
+func validateSize(rv reflect.Value) (float64, bool) {
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(rv.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), true
+	case reflect.String, reflect.Slice, reflect.Map:
+		return float64(rv.Len()), true
+	}
+	return 0, false
+}
+
+func validateField(field *field) error {
+	if len(field.validators) == 0 {
+		return nil
+	}
+
+	rv := field.fieldVal
+	if rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			return nil
+		}
+		rv = rv.Elem()
+	}
+
+	for _, validator := range field.validators {
+		if err := validator(rv); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+package cliconf
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+
+	type Config struct {
+		Port int    `flag:"port" default:"8080" validate:"min=1,max=65535"`
+		Env  string `flag:"env" default:"dev" validate:"oneof=dev staging prod"`
+		Name string `flag:"name" optional:"true" validate:"nonempty"`
+	}
+
+	for _, tc := range []struct {
+		name       string
+		args       []string
+		wantFields []string
+	}{{
+		name: "valid",
+		args: []string{"--port=443", "--env=prod", "--name=app"},
+	}, {
+		name:       "out of range",
+		args:       []string{"--port=0", "--env=test"},
+		wantFields: []string{"Port", "Env"},
+	}, {
+		name:       "too large",
+		args:       []string{"--port=70000"},
+		wantFields: []string{"Port"},
+	}, {
+		name:       "empty",
+		args:       []string{"--name="},
+		wantFields: []string{"Name"},
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := &Config{}
+			err := ParseCombined(reflect.ValueOf(cfg), tc.args)
+			if len(tc.wantFields) == 0 {
+				if err != nil {
+					t.Errorf("Expected no error, got %v", err)
+				}
+				return
+			}
+
+			paramErrors := ParamErrors{}
+			if !errors.As(err, &paramErrors) {
+				t.Fatalf("Expected ParamErrors, got %v", err)
+			}
+
+			if len(paramErrors) != len(tc.wantFields) {
+				t.Fatalf("Expected %d errors, got %v", len(tc.wantFields), paramErrors)
+			}
+			for idx, wantField := range tc.wantFields {
+				if paramErrors[idx].FieldName != wantField {
+					t.Errorf("Expected error for %s, got %s", wantField, paramErrors[idx].FieldName)
+				}
+			}
+		})
+	}
+}
+
+func TestValidateTagErrors(t *testing.T) {
+
+	type Config struct {
+		Port int `flag:"port" validate:"between=1"`
+	}
+
+	err := ParseCombined(reflect.ValueOf(&Config{}), []string{})
+	if err == nil {
+		t.Errorf("Expected error for unknown rule")
+	}
+}
+
+func TestPattern(t *testing.T) {
+
+	type Config struct {
+		Name string `flag:"name" pattern:"^[a-z0-9-]+$"`
+	}
+
+	cfg := &Config{}
+	if err := ParseCombined(reflect.ValueOf(cfg), []string{"--name=my-app"}); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+	if cfg.Name != "my-app" {
+		t.Errorf("Expected my-app, got %q", cfg.Name)
+	}
+
+	err := ParseCombined(reflect.ValueOf(&Config{}), []string{"--name=My App"})
+	paramErrors := ParamErrors{}
+	if !errors.As(err, &paramErrors) {
+		t.Fatalf("Expected ParamErrors, got %v", err)
+	}
+	if len(paramErrors) != 1 || paramErrors[0].FieldName != "Name" {
+		t.Errorf("Expected one error for Name, got %v", paramErrors)
+	}
+
+	type BadConfig struct {
+		Name string `flag:"name" pattern:"^[a-z"`
+	}
+	err = ParseCombined(reflect.ValueOf(&BadConfig{}), []string{"--name=foo"})
+	if err == nil {
+		t.Errorf("Expected error for invalid pattern")
+	} else if errors.As(err, &paramErrors) {
+		t.Errorf("Expected a setup error, got ParamErrors %v", err)
+	}
+}
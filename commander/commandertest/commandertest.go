@@ -0,0 +1,22 @@
+// Package commandertest provides helpers for testing commands built with
+// commander.
+package commandertest
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pentops/runner/commander"
+)
+
+// RunTest sets env with t.Setenv, then runs the command, which may be a
+// Command or a CommandSet, with args, as they would follow the command name
+// on the command line. The env is restored when the test ends. As with
+// t.Setenv, it can't be used in parallel tests.
+func RunTest(t testing.TB, cmd commander.Runnable, args []string, env map[string]string) error {
+	t.Helper()
+	for key, value := range env {
+		t.Setenv(key, value)
+	}
+	return cmd.Run(context.Background(), args)
+}
@@ -0,0 +1,57 @@
+package commandertest
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/pentops/runner/commander"
+)
+
+type testConfig struct {
+	Name   string `flag:"name"`
+	Region string `env:"COMMANDERTEST_REGION"`
+}
+
+func TestRunTest(t *testing.T) {
+
+	var got testConfig
+	cmd := commander.NewCommand(func(ctx context.Context, cfg testConfig) error {
+		got = cfg
+		return nil
+	})
+
+	set := commander.NewCommandSet()
+	set.Add("deploy", cmd)
+
+	for _, tc := range []struct {
+		name string
+		cmd  commander.Runnable
+		args []string
+	}{{
+		name: "command",
+		cmd:  cmd,
+		args: []string{"--name", "app"},
+	}, {
+		name: "command set",
+		cmd:  set,
+		args: []string{"deploy", "--name", "app"},
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			got = testConfig{}
+			err := RunTest(t, tc.cmd, tc.args, map[string]string{
+				"COMMANDERTEST_REGION": "east",
+			})
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if got.Name != "app" || got.Region != "east" {
+				t.Errorf("Expected app in east, got %+v", got)
+			}
+		})
+	}
+
+	if _, ok := os.LookupEnv("COMMANDERTEST_REGION"); ok {
+		t.Errorf("Expected env to be restored")
+	}
+}
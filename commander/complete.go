@@ -0,0 +1,149 @@
+package commander
+
+import (
+	"strings"
+
+	"github.com/pentops/runner/cliconf"
+)
+
+// CompleteCommand is the hidden command used by shell completion scripts to
+// ask the program for suggestions. It is handled by RunMain and RunMainErr
+// and is not listed in help.
+//
+// The protocol is:
+//
+//	<program> __complete <command path...> <args so far...> <current word>
+//
+// The last argument is the word under the cursor, which is empty when
+// completing a new word. Suggestions starting with the current word are
+// written to stdout, one per line, and the exit code is 0. No output means
+// there are no suggestions, and the shell should fall back to its default,
+// e.g. filenames.
+//
+// Command names are suggested until a command is found. Within a command,
+// words starting with `-` complete to flag names, and values of flags, given
+// either as the next word or after `=`, complete using the function
+// registered with WithCompletion, or the EnumValues of the flag type.
+const CompleteCommand = "__complete"
+
+// CompletionFunc returns suggestions for the partial word being completed.
+// Suggestions which do not start with partial are discarded.
+type CompletionFunc func(partial string) []string
+
+// WithCompletion registers a function to suggest values for the flag, e.g.
+// names of remote resources, see CompleteCommand.
+func WithCompletion(flagName string, complete CompletionFunc) func(*CommandOption) {
+	return func(co *CommandOption) {
+		if co.completions == nil {
+			co.completions = map[string]CompletionFunc{}
+		}
+		co.completions[flagName] = complete
+	}
+}
+
+type completer interface {
+	Complete(args []string) []string
+}
+
+// Complete returns suggestions for the last arg, following the
+// CompleteCommand protocol with the program name and `__complete` removed.
+func (cs *CommandSet) Complete(args []string) []string {
+	if len(args) == 0 {
+		return nil
+	}
+
+	if len(args) == 1 {
+		names := make([]string, 0, len(cs.commands))
+		for _, command := range cs.commands {
+			names = append(names, command.name)
+		}
+		return filterPrefix(names, args[0])
+	}
+
+	command, ok := cs.findCommand(args[0])
+	if !ok {
+		return nil
+	}
+	sub, ok := command.command.(completer)
+	if !ok {
+		return nil
+	}
+	return sub.Complete(args[1:])
+}
+
+// Complete returns suggestions for the last arg, which is a flag name or the
+// value of a flag, see CompleteCommand.
+func (cc *Command[C]) Complete(args []string) []string {
+	if len(args) == 0 {
+		return nil
+	}
+	words, partial := args[:len(args)-1], args[len(args)-1]
+
+	flags := map[string]cliconf.HelpLine{}
+	for _, tag := range cc.helpTags() {
+		if tag.FlagName != "" {
+			flags[tag.FlagName] = tag
+		}
+	}
+
+	if len(words) > 0 {
+		previous := words[len(words)-1]
+		if name, ok := flagName(previous); ok && !strings.Contains(name, "=") {
+			if tag, ok := flags[name]; ok && !tag.IsBool {
+				return cc.completeValue(tag, partial)
+			}
+		}
+	}
+
+	if !strings.HasPrefix(partial, "-") {
+		return nil
+	}
+
+	name, _ := flagName(partial)
+	dashes := strings.TrimSuffix(partial, name)
+	if name, value, ok := strings.Cut(name, "="); ok {
+		tag, ok := flags[name]
+		if !ok {
+			return nil
+		}
+		suggestions := cc.completeValue(tag, value)
+		for idx, suggestion := range suggestions {
+			suggestions[idx] = dashes + name + "=" + suggestion
+		}
+		return suggestions
+	}
+
+	names := make([]string, 0, len(flags))
+	for _, tag := range cc.helpTags() {
+		if tag.FlagName != "" {
+			names = append(names, "--"+tag.FlagName)
+		}
+	}
+	return filterPrefix(names, partial)
+}
+
+func (cc *Command[C]) completeValue(tag cliconf.HelpLine, partial string) []string {
+	if complete, ok := cc.completions[tag.FlagName]; ok {
+		return filterPrefix(complete(partial), partial)
+	}
+	return filterPrefix(tag.EnumValues, partial)
+}
+
+// flagName strips the leading dashes from a flag, ok is false when the word
+// is not a flag
+func flagName(word string) (string, bool) {
+	if !strings.HasPrefix(word, "-") {
+		return "", false
+	}
+	return strings.TrimPrefix(strings.TrimPrefix(word, "-"), "-"), true
+}
+
+func filterPrefix(values []string, prefix string) []string {
+	out := make([]string, 0, len(values))
+	for _, value := range values {
+		if strings.HasPrefix(value, prefix) {
+			out = append(out, value)
+		}
+	}
+	return out
+}
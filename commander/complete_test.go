@@ -0,0 +1,123 @@
+package commander
+
+import (
+	"bytes"
+	"context"
+	"slices"
+	"testing"
+)
+
+func TestComplete(t *testing.T) {
+
+	type CompleteConfig struct {
+		Mode    modeEnum `flag:"mode" default:"safe"`
+		Cluster string   `flag:"cluster" env:"CLUSTER"`
+		Verbose bool     `flag:"verbose"`
+		Target  string   `flag:",arg0"`
+	}
+
+	deploy := NewCommand(func(ctx context.Context, cfg CompleteConfig) error {
+		return nil
+	}, WithCompletion("cluster", func(partial string) []string {
+		return []string{"prod-east", "prod-west", "staging"}
+	}))
+
+	db := NewCommandSet()
+	db.Add("migrate", deploy)
+
+	root := NewCommandSet()
+	root.Add("deploy", deploy)
+	root.Add("db", db)
+
+	for _, tc := range []struct {
+		name string
+		args []string
+		want []string
+	}{{
+		name: "commands",
+		args: []string{""},
+		want: []string{"deploy", "db"},
+	}, {
+		name: "command prefix",
+		args: []string{"de"},
+		want: []string{"deploy"},
+	}, {
+		name: "nested commands",
+		args: []string{"db", "m"},
+		want: []string{"migrate"},
+	}, {
+		name: "unknown command",
+		args: []string{"nope", ""},
+		want: []string{},
+	}, {
+		name: "flag names",
+		args: []string{"deploy", "--"},
+		want: []string{"--mode", "--cluster", "--verbose"},
+	}, {
+		name: "flag name prefix",
+		args: []string{"deploy", "--c"},
+		want: []string{"--cluster"},
+	}, {
+		name: "completion func",
+		args: []string{"deploy", "--cluster", "prod"},
+		want: []string{"prod-east", "prod-west"},
+	}, {
+		name: "enum values",
+		args: []string{"deploy", "--mode", ""},
+		want: []string{"fast", "safe"},
+	}, {
+		name: "value after equals",
+		args: []string{"deploy", "--mode=f"},
+		want: []string{"--mode=fast"},
+	}, {
+		name: "after bool flag",
+		args: []string{"deploy", "--verbose", ""},
+		want: []string{},
+	}, {
+		name: "positional",
+		args: []string{"deploy", "--mode", "fast", ""},
+		want: []string{},
+	}, {
+		name: "nested flag value",
+		args: []string{"db", "migrate", "--cluster", "s"},
+		want: []string{"staging"},
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			got := root.Complete(tc.args)
+			if !slices.Equal(got, tc.want) {
+				t.Errorf("Expected %q, got %q", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestCompleteCommand(t *testing.T) {
+
+	ran := false
+	root := NewCommandSet()
+	root.Add("serve", NewCommand(func(ctx context.Context, cfg TestConfig) error {
+		ran = true
+		return nil
+	}))
+
+	out := &bytes.Buffer{}
+	errOut := &bytes.Buffer{}
+	err := root.RunMainErr("test", "1.0",
+		WithArgs([]string{"test", CompleteCommand, "serve", "--f"}),
+		WithOutputWriter(out),
+		WithErrorWriter(errOut),
+	)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if ran {
+		t.Error("Expected completion not to run the command")
+	}
+	compareLines(t, out.String(),
+		"--foo",
+		"",
+	)
+	if errOut.Len() != 0 {
+		t.Errorf("Expected no error output, got %q", errOut.String())
+	}
+}
@@ -0,0 +1,15 @@
+package commander
+
+import "context"
+
+// DryRunFlag is handled by commands created WithDryRun
+const DryRunFlag = "dry-run"
+
+type dryRunKey struct{}
+
+// IsDryRun returns true when the command was run with --dry-run, for commands
+// created WithDryRun.
+func IsDryRun(ctx context.Context) bool {
+	dryRun, _ := ctx.Value(dryRunKey{}).(bool)
+	return dryRun
+}
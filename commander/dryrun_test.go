@@ -0,0 +1,133 @@
+package commander
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDryRun(t *testing.T) {
+
+	type DeleteConfig struct {
+		Name string `flag:"name"`
+	}
+
+	var gotDryRun bool
+	var gotName string
+	cmd := NewCommand(func(ctx context.Context, cfg DeleteConfig) error {
+		gotDryRun = IsDryRun(ctx)
+		gotName = cfg.Name
+		return nil
+	}, WithDryRun())
+
+	for _, tc := range []struct {
+		name string
+		args []string
+		want bool
+	}{{
+		name: "set",
+		args: []string{"--dry-run", "--name=foo"},
+		want: true,
+	}, {
+		name: "after flags",
+		args: []string{"--name=foo", "--dry-run"},
+		want: true,
+	}, {
+		name: "unset",
+		args: []string{"--name=foo"},
+		want: false,
+	}, {
+		name: "value true",
+		args: []string{"--dry-run=true", "--name=foo"},
+		want: true,
+	}, {
+		name: "value false",
+		args: []string{"--dry-run=false", "--name=foo"},
+		want: false,
+	}, {
+		name: "separate value",
+		args: []string{"--dry-run", "false", "--name=foo"},
+		want: false,
+	}, {
+		name: "single dash",
+		args: []string{"-dry-run", "--name=foo"},
+		want: true,
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			gotDryRun = !tc.want
+			if err := cmd.Run(context.Background(), tc.args); err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if gotDryRun != tc.want {
+				t.Errorf("Expected IsDryRun %v, got %v", tc.want, gotDryRun)
+			}
+			if gotName != "foo" {
+				t.Errorf("Expected name foo, got %q", gotName)
+			}
+		})
+	}
+
+	t.Run("invalid value", func(t *testing.T) {
+		err := cmd.Run(context.Background(), []string{"--dry-run=maybe", "--name=foo"})
+		if !errors.As(err, &HelpError{}) {
+			t.Errorf("Expected a HelpError, got %v", err)
+		}
+	})
+
+	t.Run("passthrough", func(t *testing.T) {
+		type ExecConfig struct {
+			Command string   `flag:",arg0"`
+			Args    []string `flag:",passthrough"`
+		}
+		var gotArgs []string
+		exec := NewCommand(func(ctx context.Context, cfg ExecConfig) error {
+			gotDryRun = IsDryRun(ctx)
+			gotArgs = cfg.Args
+			return nil
+		}, WithDryRun())
+		if err := exec.Run(context.Background(), []string{"kubectl", "apply", "--dry-run"}); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if gotDryRun {
+			t.Error("Expected dry run not to be set by a passthrough arg")
+		}
+		if want := []string{"apply", "--dry-run"}; !reflect.DeepEqual(gotArgs, want) {
+			t.Errorf("Expected args %q, got %q", want, gotArgs)
+		}
+	})
+
+	t.Run("help", func(t *testing.T) {
+		if help := cmd.Help(); !strings.Contains(help, "--dry-run") {
+			t.Errorf("Expected --dry-run in help, got %q", help)
+		}
+	})
+
+	t.Run("not enabled", func(t *testing.T) {
+		plain := NewCommand(func(ctx context.Context, cfg DeleteConfig) error {
+			return nil
+		})
+		if err := plain.Run(context.Background(), []string{"--dry-run", "--name=foo"}); err == nil {
+			t.Error("Expected --dry-run to be an unknown flag")
+		}
+	})
+
+	t.Run("own flag", func(t *testing.T) {
+		type OwnConfig struct {
+			DryRun bool `flag:"dry-run"`
+		}
+		var cfgDryRun, ctxDryRun bool
+		own := NewCommand(func(ctx context.Context, cfg OwnConfig) error {
+			cfgDryRun = cfg.DryRun
+			ctxDryRun = IsDryRun(ctx)
+			return nil
+		}, WithDryRun())
+		if err := own.Run(context.Background(), []string{"--dry-run"}); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if !cfgDryRun || ctxDryRun {
+			t.Errorf("Expected the config's own flag to be set, got config %v, context %v", cfgDryRun, ctxDryRun)
+		}
+	})
+}
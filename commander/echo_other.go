@@ -0,0 +1,9 @@
+//go:build !linux && !darwin && !dragonfly && !freebsd && !netbsd && !openbsd
+
+package commander
+
+import "bufio"
+
+// readNoEcho is not supported on this platform, secret fields are not
+// prompted for.
+var readNoEcho func(*bufio.Reader) (string, error)
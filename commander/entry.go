@@ -1,11 +1,17 @@
 package commander
 
 import (
+	"bufio"
 	"context"
 	"errors"
 	"fmt"
+	"os"
 	"reflect"
+	"slices"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/pentops/runner/cliconf"
 )
@@ -18,6 +24,16 @@ type Command[C any] struct {
 type CommandOption struct {
 	description     string
 	outcomeCallback func(context.Context, error)
+	sortFlags       bool
+	helpWidth       int
+	interactive     bool
+	configObserver  func(context.Context, any)
+	timingCallback  func(ctx context.Context, parseDuration, runDuration time.Duration, err error)
+	defaultEnvFile  string
+	completions     map[string]CompletionFunc
+	argsFiles       bool
+	printConfig     bool
+	dryRun          bool
 }
 
 func WithDescription(description string) func(*CommandOption) {
@@ -26,6 +42,151 @@ func WithDescription(description string) func(*CommandOption) {
 	}
 }
 
+// WithSortedFlags lists flags alphabetically in help, rather than in the order
+// they are declared in the config struct. Positional args are listed after
+// flags, in their declared order.
+func WithSortedFlags() func(*CommandOption) {
+	return func(co *CommandOption) {
+		co.sortFlags = true
+	}
+}
+
+// WithHelpWidth sets the width that flag descriptions in help are wrapped to,
+// overriding the detected terminal width. A negative width disables wrapping.
+func WithHelpWidth(width int) func(*CommandOption) {
+	return func(co *CommandOption) {
+		co.helpWidth = width
+	}
+}
+
+// WithConfigObserver registers a function which receives the populated config
+// after it is successfully parsed, before the callback runs, e.g. to log the
+// effective configuration centrally.
+func WithConfigObserver(observer func(context.Context, any)) func(*CommandOption) {
+	return func(co *CommandOption) {
+		co.configObserver = observer
+	}
+}
+
+// WithTimingCallback registers a function which receives how long parsing and
+// the callback took, and the outcome. It is called once for every Run, with a
+// zero run duration whenever the callback doesn't run: when parsing fails,
+// help is requested, or --print-config prints the config. If the callback
+// panics, it is called with an error while the panic carries on.
+func WithTimingCallback(timingCallback func(ctx context.Context, parseDuration, runDuration time.Duration, err error)) func(*CommandOption) {
+	return func(co *CommandOption) {
+		co.timingCallback = timingCallback
+	}
+}
+
+// WithDefaultEnvFile loads the env file at path when it exists and --envfile
+// is not given, see cliconf.WithDefaultEnvFile
+func WithDefaultEnvFile(path string) func(*CommandOption) {
+	return func(co *CommandOption) {
+		co.defaultEnvFile = path
+	}
+}
+
+// WithArgsFiles expands args starting with @ into the args in the named file,
+// see cliconf.ExpandArgsFiles
+func WithArgsFiles() func(*CommandOption) {
+	return func(co *CommandOption) {
+		co.argsFiles = true
+	}
+}
+
+// PrintConfigFlag is handled by commands created WithPrintConfig
+const PrintConfigFlag = "print-config"
+
+// WithPrintConfig handles --print-config by printing the effective config to
+// Stdout(ctx) rather than running the callback. Each field is printed as
+// `Name=value # origin`, sorted by name, with secrets masked, see
+// cliconf.Describe and cliconf.ParseResult.Origin.
+func WithPrintConfig() func(*CommandOption) {
+	return func(co *CommandOption) {
+		co.printConfig = true
+	}
+}
+
+// WithDryRun handles a --dry-run flag for the command, which callbacks read
+// with IsDryRun(ctx), so that every command in a CLI has the same dry run
+// flag without declaring it in their config. A config which has its own
+// dry-run flag takes precedence.
+func WithDryRun() func(*CommandOption) {
+	return func(co *CommandOption) {
+		co.dryRun = true
+	}
+}
+
+// handlesDryRun is true when the command adds --dry-run to the config's flags
+func (cc *Command[C]) handlesDryRun() bool {
+	if !cc.dryRun {
+		return false
+	}
+	for _, tag := range cc.configTags() {
+		if tag.FlagName == DryRunFlag {
+			return false
+		}
+	}
+	return true
+}
+
+// popFlag removes a boolean flag handled by commander from the leading flags,
+// returning its value. As with cliconf's booleans, the flag may have one or
+// two dashes, and be given as `--name`, `--name=value` or `--name true`.
+// Positional args and everything after them, including passthrough args, are
+// left alone.
+func (cc *Command[C]) popFlag(args []string, name string) ([]string, bool, error) {
+	end := cc.flagsEnd(args)
+	for idx, arg := range args[:end] {
+		given, value, hasValue := strings.Cut(arg, "=")
+		if given != "--"+name && given != "-"+name {
+			continue
+		}
+		consumed := 1
+		if !hasValue {
+			value = "true"
+			if idx+1 < end {
+				if next := strings.ToLower(args[idx+1]); next == "true" || next == "false" {
+					value = next
+					consumed = 2
+				}
+			}
+		}
+		set, err := strconv.ParseBool(strings.ToLower(value))
+		if err != nil {
+			return nil, false, HelpError{
+				Usage: cc.usage(),
+				Lines: append([]string{fmt.Sprintf("  --%s : %s", name, err)}, cc.helpSections()...),
+				Err: cliconf.ParamErrors{{
+					Flag: name,
+					Err:  err,
+				}},
+			}
+		}
+		return append(slices.Clone(args[:idx]), args[idx+consumed:]...), set, nil
+	}
+	return args, false, nil
+}
+
+func printConfig(ctx context.Context, config any, result *cliconf.ParseResult) {
+	described := cliconf.Describe(reflect.ValueOf(config))
+	names := make([]string, 0, len(described))
+	for name := range described {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	out := Stdout(ctx)
+	for _, name := range names {
+		line := fmt.Sprintf("%s=%s", name, described[name])
+		if origin := result.Origin(name); origin != "" {
+			line += " # " + string(origin)
+		}
+		fmt.Fprintln(out, line)
+	}
+}
+
 func WithOutcomeCallback(outcomeCallback func(context.Context, error)) func(*CommandOption) {
 	return func(co *CommandOption) {
 		co.outcomeCallback = outcomeCallback
@@ -44,57 +205,422 @@ func NewCommand[C any](callback func(context.Context, C) error, options ...func(
 	}
 }
 
-func (cc *Command[C]) helpLines(prefix string) []string {
+// helpTags lists the fields of the config, along with the flags handled by
+// commander
+func (cc *Command[C]) helpTags() []cliconf.HelpLine {
+	tags := cc.configTags()
+	if cc.handlesDryRun() {
+		tags = append(tags, cliconf.HelpLine{
+			FlagName:    DryRunFlag,
+			Description: "show what would be done without doing it",
+			IsBool:      true,
+		})
+	}
+	return tags
+}
+
+func (cc *Command[C]) configTags() []cliconf.HelpLine {
 	config := new(C)
 	rt := reflect.ValueOf(config).Elem().Type()
-	helpTags := cliconf.GetHelpLines(rt)
-	lines := make([][]string, 0, rt.NumField())
-	for _, tag := range helpTags {
-		description := tag.Description
+	return cliconf.GetHelpLines(rt)
+}
 
-		if tag.Default != nil {
+func isPositional(tag cliconf.HelpLine) bool {
+	return tag.ArgN != nil || tag.Remaining
+}
+
+func helpLineDescription(tag cliconf.HelpLine) string {
+	description := tag.Description
+
+	if len(tag.EnumValues) > 0 {
+		description += fmt.Sprintf(" (one of: %s)", strings.Join(tag.EnumValues, ", "))
+	}
+
+	if tag.Default != nil {
+		if tag.Secret {
+			description += " (default: ****)"
+		} else {
 			description += fmt.Sprintf(" (default: %s)", *tag.Default)
 		}
+	}
 
-		name := ""
-		if tag.FlagName != "" && tag.EnvName != "" {
-			name = fmt.Sprintf("--%s / $%s", tag.FlagName, tag.EnvName)
-		} else if tag.FlagName != "" {
-			name = fmt.Sprintf("--%s", tag.FlagName)
-		} else if tag.EnvName != "" {
-			name = fmt.Sprintf("$%s", tag.EnvName)
-		} else if tag.ArgN != nil {
-			name = fmt.Sprintf("<arg%d>", *tag.ArgN)
-		} else if tag.Remaining {
-			name = "<remaining args>"
-		} else {
-			name = "<unknown>"
+	if tag.Deprecated != "" {
+		description += fmt.Sprintf(" (deprecated: %s)", tag.Deprecated)
+	}
+	return description
+}
+
+func helpLineName(tag cliconf.HelpLine) string {
+	flagName := ""
+	if tag.FlagName != "" {
+		flagName = "--" + tag.FlagName
+		if tag.Placeholder != "" {
+			flagName += " " + tag.Placeholder
+		}
+	}
+
+	if tag.FlagName != "" && tag.EnvName != "" {
+		return fmt.Sprintf("%s / $%s", flagName, tag.EnvName)
+	} else if tag.FlagName != "" {
+		return flagName
+	} else if tag.EnvName != "" {
+		return fmt.Sprintf("$%s", tag.EnvName)
+	}
+	return positionalName(tag)
+}
+
+// positionalName names the positional arg, ignoring any flag which can be
+// used instead
+func positionalName(tag cliconf.HelpLine) string {
+	if tag.ArgN != nil && tag.ArgRange {
+		return fmt.Sprintf("<arg%d...>", *tag.ArgN)
+	} else if tag.ArgN != nil {
+		return fmt.Sprintf("<arg%d>", *tag.ArgN)
+	} else if tag.Passthrough {
+		return "<passthrough args>"
+	} else if tag.Remaining {
+		return "<remaining args>"
+	} else if tag.Unknown {
+		return "<other flags>"
+	}
+	return "<unknown>"
+}
+
+func (cc *Command[C]) width() int {
+	if cc.helpWidth == 0 {
+		return terminalWidth()
+	}
+	return cc.helpWidth
+}
+
+// helpLines lists the flags and env vars, positional args are listed
+// separately by argHelpLines
+func (cc *Command[C]) helpLines(prefix string) []string {
+	helpTags := cc.helpTags()
+	if cc.sortFlags {
+		sortHelpLines(helpTags)
+	}
+
+	// Ungrouped lines come first, then each group in order of first appearance
+	groupNames := []string{""}
+	groups := map[string][][]string{}
+	for _, tag := range helpTags {
+		if isPositional(tag) && tag.FlagName == "" {
+			continue
+		}
+
+		if _, ok := groups[tag.Group]; !ok && tag.Group != "" {
+			groupNames = append(groupNames, tag.Group)
+		}
+		description := helpLineDescription(tag)
+		if tag.Required {
+			description += " (required)"
+		}
+		groups[tag.Group] = append(groups[tag.Group], []string{helpLineName(tag), description})
+	}
+
+	width := cc.width()
+	out := evenJoin(prefix, width, groups[""])
+	for _, groupName := range groupNames[1:] {
+		out = append(out, prefix+groupName+":")
+		out = append(out, evenJoin(prefix+"  ", width, groups[groupName])...)
+	}
+	return out
+}
+
+// positionalTags returns the positional args in the order they are consumed,
+// with remaining args last
+func (cc *Command[C]) positionalTags() []cliconf.HelpLine {
+	positional := make([]cliconf.HelpLine, 0)
+	for _, tag := range cc.helpTags() {
+		if isPositional(tag) {
+			positional = append(positional, tag)
+		}
+	}
+	sort.SliceStable(positional, func(i, j int) bool {
+		if positional[i].ArgN == nil || positional[j].ArgN == nil {
+			return positional[j].ArgN == nil && positional[i].ArgN != nil
+		}
+		return *positional[i].ArgN < *positional[j].ArgN
+	})
+	return positional
+}
+
+func (cc *Command[C]) argHelpLines(prefix string) []string {
+	lines := make([][]string, 0)
+	for _, tag := range cc.positionalTags() {
+		description := helpLineDescription(tag)
+		if tag.FlagName != "" {
+			description += fmt.Sprintf(" (or --%s)", tag.FlagName)
+		} else if tag.EnvName != "" && !tag.ArgRange {
+			description += fmt.Sprintf(" (or $%s)", tag.EnvName)
+		}
+		lines = append(lines, []string{positionalName(tag), description})
+	}
+	return evenJoin(prefix, cc.width(), lines)
+}
+
+// usage describes the positional args, e.g. `<arg0> [remaining...] [options]`.
+// Optional args are wrapped in brackets. Commands with no config have an empty
+// usage.
+func (cc *Command[C]) usage() string {
+	parts := make([]string, 0)
+	for _, tag := range cc.positionalTags() {
+		if tag.Passthrough {
+			parts = append(parts, "[args...]")
+			continue
+		}
+		if tag.Remaining {
+			parts = append(parts, "[remaining...]")
+			continue
 		}
+		part := positionalName(tag)
+		if !tag.Required || tag.FlagName != "" || (tag.EnvName != "" && !tag.ArgRange) {
+			part = "[" + part + "]"
+		}
+		parts = append(parts, part)
+	}
+	if len(cc.helpTags()) > 0 {
+		parts = append(parts, "[options]")
+	}
+	return strings.Join(parts, " ")
+}
 
-		lines = append(lines, []string{name, description})
+func helpLineSortKey(line cliconf.HelpLine) string {
+	if line.FlagName != "" {
+		return line.FlagName
 	}
-	return evenJoin(prefix, lines)
+	return line.EnvName
+}
+
+func sortHelpLines(lines []cliconf.HelpLine) {
+	sort.SliceStable(lines, func(i, j int) bool {
+		keyI := helpLineSortKey(lines[i])
+		keyJ := helpLineSortKey(lines[j])
+		if keyI == "" || keyJ == "" {
+			// positional args go last, keeping their order
+			return keyJ == "" && keyI != ""
+		}
+		return strings.ToLower(keyI) < strings.ToLower(keyJ)
+	})
 }
 
 func (cc *Command[C]) Help() string {
 	lines := cc.helpLines("  ")
+	if argLines := cc.argHelpLines("  "); len(argLines) > 0 {
+		lines = append(lines, "Args:")
+		lines = append(lines, argLines...)
+	}
 	return cc.description + "\n" + strings.Join(lines, "\n")
 }
 
+// Normalizer is implemented by configs which derive or clean up fields after
+// parsing. Normalize is called on a pointer to the config, before Validate and
+// the callback.
+type Normalizer interface {
+	Normalize()
+}
+
+// Validator is implemented by configs which check more than the tags can,
+// e.g. combinations of fields. Validate is called on a pointer to the config
+// after parsing and Normalize, an error is returned as a HelpError wrapping it
+// rather than running the callback.
+type Validator interface {
+	Validate() error
+}
+
 type HelpError struct {
 	Usage string
 	Lines []string
+
+	// Err is the underlying error, if any, e.g. cliconf.ParamErrors
+	Err error
 }
 
 func (he HelpError) Error() string {
 	return strings.Join(he.Lines, "\n")
 }
 
+func (he HelpError) Unwrap() error {
+	return he.Err
+}
+
+// ErrHelpRequested is returned, wrapped in a HelpError, when help is asked for
+// with `--help` or `-h`. RunMain prints it to stdout rather than stderr, and
+// doesn't treat it as a failure.
+var ErrHelpRequested = errors.New("help requested")
+
+func isHelpArg(arg string) bool {
+	return arg == "--help" || arg == "-h"
+}
+
+// flagsEnd returns the index of the first positional arg, or of the `--`
+// terminator, splitting the args as cliconf does: flags which are not
+// booleans take the following arg as their value unless given as
+// `--name=value`. Flags handled by commander itself are all booleans.
+func (cc *Command[C]) flagsEnd(args []string) int {
+	booleans := map[string]struct{}{
+		"help": {},
+		"h":    {},
+	}
+	if cc.printConfig {
+		booleans[PrintConfigFlag] = struct{}{}
+	}
+	for _, tag := range cc.helpTags() {
+		if tag.IsBool {
+			booleans[tag.FlagName] = struct{}{}
+		}
+	}
+
+	idx := 0
+	for idx < len(args) {
+		arg := args[idx]
+		if arg == "--" || !strings.HasPrefix(arg, "-") {
+			return idx
+		}
+		idx++
+		name, _, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		if hasValue || idx >= len(args) {
+			continue
+		}
+		if _, ok := booleans[name]; ok {
+			// booleans only consume an explicit true or false
+			if next := strings.ToLower(args[idx]); next == "true" || next == "false" {
+				idx++
+			}
+			continue
+		}
+		idx++
+	}
+	return idx
+}
+
+// helpRequested is true when the leading flags include `--help` or `-h`,
+// unless the config has its own flag of that name. Positional args, and
+// everything after them, are not checked, so that passthrough args can
+// include their own help flags.
+func (cc *Command[C]) helpRequested(args []string) bool {
+	for _, tag := range cc.helpTags() {
+		if tag.FlagName == "help" || tag.FlagName == "h" {
+			return false
+		}
+	}
+	return slices.ContainsFunc(args[:cc.flagsEnd(args)], isHelpArg)
+}
+
+// helpSections lists the flags and env vars, then the positional args, under
+// headings
+func (cc *Command[C]) helpSections() []string {
+	lines := make([]string, 0)
+	if flagLines := cc.helpLines("  "); len(flagLines) > 0 {
+		lines = append(lines, "Flags and Env Vars:")
+		lines = append(lines, flagLines...)
+	}
+	if argLines := cc.argHelpLines("  "); len(argLines) > 0 {
+		lines = append(lines, "Args:")
+		lines = append(lines, argLines...)
+	}
+	return lines
+}
+
+// errCallbackPanicked is passed to the timing callback when the callback
+// panics, the panic itself carries on unrecovered
+var errCallbackPanicked = errors.New("callback panicked")
+
+func (cc *Command[C]) reportTiming(ctx context.Context, parseDuration, runDuration time.Duration, err error) {
+	if cc.timingCallback != nil {
+		cc.timingCallback(ctx, parseDuration, runDuration, err)
+	}
+}
+
 func (cc *Command[C]) Run(ctx context.Context, args []string) error {
+	parseStart := time.Now()
+	if cc.helpRequested(args) {
+		lines := cc.helpSections()
+		if cc.description != "" {
+			lines = append([]string{cc.description}, lines...)
+		}
+		err := HelpError{
+			Usage: cc.usage(),
+			Lines: lines,
+			Err:   ErrHelpRequested,
+		}
+		cc.reportTiming(ctx, time.Since(parseStart), 0, err)
+		return err
+	}
+
+	printOnly := false
+	if cc.printConfig {
+		var err error
+		args, printOnly, err = cc.popFlag(args, PrintConfigFlag)
+		if err != nil {
+			cc.reportTiming(ctx, time.Since(parseStart), 0, err)
+			return err
+		}
+	}
+	if cc.handlesDryRun() {
+		var dryRun bool
+		var err error
+		args, dryRun, err = cc.popFlag(args, DryRunFlag)
+		if err != nil {
+			cc.reportTiming(ctx, time.Since(parseStart), 0, err)
+			return err
+		}
+		ctx = context.WithValue(ctx, dryRunKey{}, dryRun)
+	}
+
+	config, result, parseError := cc.parse(ctx, args)
+	parseDuration := time.Since(parseStart)
+	if parseError != nil {
+		cc.reportTiming(ctx, parseDuration, 0, parseError)
+		return parseError
+	}
+
+	if printOnly {
+		printConfig(ctx, config, result)
+		cc.reportTiming(ctx, parseDuration, 0, nil)
+		return nil
+	}
+
+	if cc.configObserver != nil {
+		cc.configObserver(ctx, *config)
+	}
+
+	runStart := time.Now()
+	returned := false
+	defer func() {
+		// Not recovered, so the panic keeps its original stack
+		if !returned {
+			cc.reportTiming(ctx, parseDuration, time.Since(runStart), errCallbackPanicked)
+		}
+	}()
+
+	mainErr := cc.Callback(ctx, *config)
+	returned = true
+	cc.reportTiming(ctx, parseDuration, time.Since(runStart), mainErr)
+	if cc.outcomeCallback != nil {
+		cc.outcomeCallback(ctx, mainErr)
+	}
+	return mainErr
+}
+
+func (cc *Command[C]) parse(ctx context.Context, args []string) (*C, *cliconf.ParseResult, error) {
 	config := new(C)
 	configValue := reflect.ValueOf(config).Elem()
 
-	parseError := cliconf.ParseCombined(configValue, args)
+	parseOptions := []cliconf.ParseOption{cliconf.WithContext(ctx)}
+	if cc.defaultEnvFile != "" {
+		parseOptions = append(parseOptions, cliconf.WithDefaultEnvFile(cc.defaultEnvFile))
+	}
+	if cc.argsFiles {
+		parseOptions = append(parseOptions, cliconf.WithArgsFiles())
+	}
+	if cc.interactive && stdinIsTerminal() {
+		prompt := terminalPrompt(bufio.NewReader(os.Stdin), os.Stderr, readNoEcho)
+		parseOptions = append(parseOptions, cliconf.WithPrompt(prompt))
+	}
+
+	result, parseError := cliconf.ParseWithResult(configValue, args, parseOptions...)
 	if parseError != nil {
 		if paramErrors := new(cliconf.ParamErrors); errors.As(parseError, paramErrors) {
 			lines := make([]string, 0, len(*paramErrors))
@@ -106,28 +632,40 @@ func (cc *Command[C]) Run(ctx context.Context, args []string) error {
 					name = fmt.Sprintf("--%s", err.Flag)
 				} else if err.Env != "" {
 					name = fmt.Sprintf("$%s", err.Env)
+				} else if err.ArgN != nil {
+					name = fmt.Sprintf("<arg%d>", *err.ArgN)
 				} else if err.FieldName != "" {
 					name = err.FieldName
 				} else {
-					name = "<unknown>"
+					lines = append(lines, fmt.Sprintf("  %s", err.Err))
+					continue
 				}
 				lines = append(lines, fmt.Sprintf("  %s : %s", name, err.Err))
 			}
 
-			lines = append(lines, "Flags and Env Vars:")
-			lines = append(lines, cc.helpLines("  ")...)
+			lines = append(lines, cc.helpSections()...)
 
-			return HelpError{
-				Usage: "[options]",
+			return nil, nil, HelpError{
+				Usage: cc.usage(),
 				Lines: lines,
+				Err:   *paramErrors,
 			}
 		}
-		return parseError
+		return nil, nil, parseError
 	}
 
-	mainErr := cc.Callback(ctx, *config)
-	if cc.outcomeCallback != nil {
-		cc.outcomeCallback(ctx, mainErr)
+	if normalizer, ok := any(config).(Normalizer); ok {
+		normalizer.Normalize()
 	}
-	return mainErr
+	if validator, ok := any(config).(Validator); ok {
+		if err := validator.Validate(); err != nil {
+			lines := append([]string{fmt.Sprintf("  %s", err)}, cc.helpSections()...)
+			return nil, nil, HelpError{
+				Usage: cc.usage(),
+				Lines: lines,
+				Err:   err,
+			}
+		}
+	}
+	return config, result, nil
 }
@@ -3,8 +3,14 @@ package commander
 import (
 	"bytes"
 	"context"
+	"errors"
+	"fmt"
+	"reflect"
 	"strings"
 	"testing"
+	"time"
+
+	"github.com/pentops/runner/cliconf"
 )
 
 type TestConfig struct {
@@ -168,7 +174,7 @@ func TestSetHelp(t *testing.T) {
 
 	t.Run("No first arg", func(t *testing.T) {
 		capture := &bytes.Buffer{}
-		root.runMain(context.Background(), capture, []string{"test"})
+		root.runMain(context.Background(), capture, capture, []string{"test"})
 		compareLines(t, capture.String(),
 			"Usage: test <command> [options]",
 			"  name        - foo description",
@@ -183,7 +189,7 @@ func TestSetHelp(t *testing.T) {
 
 	t.Run("Unknown command", func(t *testing.T) {
 		capture := &bytes.Buffer{}
-		root.runMain(context.Background(), capture, []string{"test", "unknown"})
+		root.runMain(context.Background(), capture, capture, []string{"test", "unknown"})
 		compareLines(t, capture.String(),
 			"Unknown command: 'unknown'",
 			"  name        - foo description",
@@ -198,7 +204,7 @@ func TestSetHelp(t *testing.T) {
 
 	t.Run("No sub command", func(t *testing.T) {
 		capture := &bytes.Buffer{}
-		root.runMain(context.Background(), capture, []string{"test", "longer-name"})
+		root.runMain(context.Background(), capture, capture, []string{"test", "longer-name"})
 		compareLines(t, capture.String(),
 			"Usage: test longer-name <command> [options]",
 			"  sub-1   - sub-1 description",
@@ -211,26 +217,26 @@ func TestSetHelp(t *testing.T) {
 
 	t.Run("Missing Flag Root", func(t *testing.T) {
 		capture := &bytes.Buffer{}
-		root.runMain(context.Background(), capture, []string{"test", "name"})
+		root.runMain(context.Background(), capture, capture, []string{"test", "name"})
 		compareLines(t, capture.String(),
 			"Usage: test name [options]",
 			"  --foo / $FOO : required",
 			"Flags and Env Vars:",
-			"  --foo / $FOO - foo description",
-			"  --bar / $BAR - bar description (default: bar)",
+			"  --foo <string> / $FOO - foo description (required)",
+			"  --bar <string> / $BAR - bar description (default: bar)",
 			"",
 		)
 	})
 
 	t.Run("Missing Flag Sub", func(t *testing.T) {
 		capture := &bytes.Buffer{}
-		root.runMain(context.Background(), capture, []string{"test", "longer-name", "sub-1"})
+		root.runMain(context.Background(), capture, capture, []string{"test", "longer-name", "sub-1"})
 		compareLines(t, capture.String(),
 			"Usage: test longer-name sub-1 [options]",
 			"  --foo / $FOO : required",
 			"Flags and Env Vars:",
-			"  --foo / $FOO - foo description",
-			"  --bar / $BAR - bar description (default: bar)",
+			"  --foo <string> / $FOO - foo description (required)",
+			"  --bar <string> / $BAR - bar description (default: bar)",
 			"",
 		)
 	})
@@ -248,12 +254,146 @@ func TestCommandHelp(t *testing.T) {
 	helpString := cc.Help()
 	compareLines(t, helpString,
 		"foo description",
-		"  --foo / $FOO - foo description",
-		"  --bar / $BAR - bar description (default: bar)",
+		"  --foo <string> / $FOO - foo description (required)",
+		"  --bar <string> / $BAR - bar description (default: bar)",
 	)
 
 }
 
+func TestSecretHelp(t *testing.T) {
+
+	type SecretConfig struct {
+		Password string `flag:"password" env:"PASSWORD" default:"hunter2" secret:"true" description:"db password"`
+	}
+
+	cc := NewCommand(func(ctx context.Context, cfg SecretConfig) error {
+		return nil
+	})
+
+	helpString := cc.Help()
+	if strings.Contains(helpString, "hunter2") {
+		t.Errorf("Secret default should not be shown in help: %s", helpString)
+	}
+	compareLines(t, helpString,
+		"",
+		"  --password <string> / $PASSWORD - db password (default: ****)",
+	)
+}
+
+func TestParamErrorsFromCommand(t *testing.T) {
+
+	root := NewCommandSet()
+	root.Add("name", NewCommand(func(ctx context.Context, cfg TestConfig) error {
+		return nil
+	}))
+
+	err := root.Run(context.Background(), []string{"name"})
+	paramErrors := cliconf.ParamErrors{}
+	if !errors.As(err, &paramErrors) {
+		t.Fatalf("Expected ParamErrors, got %v", err)
+	}
+
+	if paramErrors.ErrorsByField()["Foo"] == nil {
+		t.Errorf("Expected error for Foo, got %v", paramErrors)
+	}
+}
+
+func TestConfigObserver(t *testing.T) {
+
+	var observed any
+	var gotConfig TestConfig
+
+	cc := NewCommand(func(ctx context.Context, cfg TestConfig) error {
+		gotConfig = cfg
+		return nil
+	}, WithConfigObserver(func(ctx context.Context, cfg any) {
+		observed = cfg
+	}))
+
+	if err := cc.Run(context.Background(), []string{"--foo=foo"}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	observedConfig, ok := observed.(TestConfig)
+	if !ok {
+		t.Fatalf("Expected TestConfig, got %T", observed)
+	}
+	if observedConfig != gotConfig {
+		t.Errorf("Expected observer to receive %v, got %v", gotConfig, observedConfig)
+	}
+
+	observed = nil
+	if err := cc.Run(context.Background(), []string{}); err == nil {
+		t.Fatalf("Expected parse error")
+	}
+	if observed != nil {
+		t.Errorf("Expected observer not to run on parse failure")
+	}
+}
+
+func TestTimingCallback(t *testing.T) {
+
+	type timing struct {
+		parseDuration time.Duration
+		runDuration   time.Duration
+		err           error
+	}
+	var timings []timing
+
+	timingCallback := WithTimingCallback(func(ctx context.Context, parseDuration, runDuration time.Duration, err error) {
+		timings = append(timings, timing{parseDuration, runDuration, err})
+	})
+
+	cc := NewCommand(func(ctx context.Context, cfg TestConfig) error {
+		if cfg.Foo == "panic" {
+			panic("callback panic")
+		}
+		time.Sleep(time.Millisecond)
+		return nil
+	}, timingCallback, WithPrintConfig(), WithDryRun())
+
+	if err := cc.Run(context.Background(), []string{"--foo=foo"}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(timings) != 1 || timings[0].err != nil || timings[0].runDuration < time.Millisecond {
+		t.Errorf("Unexpected timing %v", timings)
+	}
+
+	timings = nil
+	if err := cc.Run(context.Background(), []string{}); err == nil {
+		t.Fatalf("Expected parse error")
+	}
+	if len(timings) != 1 || timings[0].err == nil || timings[0].runDuration != 0 {
+		t.Errorf("Unexpected timing for parse failure %v", timings)
+	}
+
+	for _, args := range [][]string{
+		{"--help"},
+		{"--dry-run=maybe", "--foo=foo"},
+		{"--print-config", "--foo=foo"},
+	} {
+		timings = nil
+		ctx := context.WithValue(context.Background(), stdoutKey{}, &bytes.Buffer{})
+		err := cc.Run(ctx, args)
+		if len(timings) != 1 || (timings[0].err == nil) != (err == nil) || timings[0].runDuration != 0 {
+			t.Errorf("%q: Unexpected timing %v", args, timings)
+		}
+	}
+
+	timings = nil
+	func() {
+		defer func() {
+			if r := recover(); r != "callback panic" {
+				t.Errorf("Expected the panic to propagate, got %v", r)
+			}
+		}()
+		_ = cc.Run(context.Background(), []string{"--foo=panic"})
+	}()
+	if len(timings) != 1 || timings[0].err == nil {
+		t.Errorf("Expected timing for panic, got %v", timings)
+	}
+}
+
 func compareLines(t *testing.T, got string, wantLines ...string) {
 	gotLines := strings.Split(got, "\n")
 	t.Log("Compare Lines")
@@ -273,3 +413,440 @@ func compareLines(t *testing.T, got string, wantLines ...string) {
 	}
 
 }
+
+func TestPlaceholderHelp(t *testing.T) {
+
+	type PlaceholderConfig struct {
+		Port    int           `flag:"port" env:"PORT" placeholder:"PORT" description:"listen port"`
+		Timeout time.Duration `flag:"timeout" default:"5s" description:"request timeout"`
+		Verbose bool          `flag:"verbose" description:"verbose output"`
+	}
+
+	cc := NewCommand(func(ctx context.Context, cfg PlaceholderConfig) error {
+		return nil
+	})
+
+	compareLines(t, cc.Help(),
+		"",
+		"  --port PORT / $PORT  - listen port (required)",
+		"  --timeout <duration> - request timeout (default: 5s)",
+		"  --verbose            - verbose output",
+	)
+}
+
+func TestGroupHelp(t *testing.T) {
+
+	type GroupConfig struct {
+		Cert    string `flag:"tls-cert" group:"TLS" description:"certificate file"`
+		Port    int    `flag:"port" description:"listen port"`
+		Key     string `flag:"tls-key" group:"TLS" description:"key file"`
+		DBHost  string `flag:"db-host" group:"Database" description:"database host"`
+		Verbose bool   `flag:"verbose" description:"verbose output"`
+	}
+
+	cc := NewCommand(func(ctx context.Context, cfg GroupConfig) error {
+		return nil
+	})
+
+	compareLines(t, cc.Help(),
+		"",
+		"  --port <int> - listen port (required)",
+		"  --verbose    - verbose output",
+		"  TLS:",
+		"    --tls-cert <string> - certificate file (required)",
+		"    --tls-key <string>  - key file (required)",
+		"  Database:",
+		"    --db-host <string> - database host (required)",
+	)
+}
+
+func TestSortedHelp(t *testing.T) {
+
+	type UnsortedConfig struct {
+		Zeta  string `flag:"zeta" description:"last"`
+		Arg   string `flag:",arg0" description:"positional"`
+		Alpha string `flag:"alpha" description:"first"`
+		Mid   string `env:"MID" description:"middle"`
+	}
+
+	cc := NewCommand(func(ctx context.Context, cfg UnsortedConfig) error {
+		return nil
+	}, WithSortedFlags())
+
+	compareLines(t, cc.Help(),
+		"",
+		"  --alpha <string> - first (required)",
+		"  $MID             - middle (required)",
+		"  --zeta <string>  - last (required)",
+		"Args:",
+		"  <arg0> - positional",
+	)
+}
+
+func TestWrappedHelp(t *testing.T) {
+
+	type LongConfig struct {
+		Mode string `flag:"mode" description:"the mode to run in, which controls how requests are routed between the primary and replica databases"`
+		Port int    `flag:"port" description:"listen port"`
+	}
+
+	cc := NewCommand(func(ctx context.Context, cfg LongConfig) error {
+		return nil
+	}, WithHelpWidth(60))
+
+	compareLines(t, cc.Help(),
+		"",
+		"  --mode <string> - the mode to run in, which controls how",
+		"                    requests are routed between the primary",
+		"                    and replica databases (required)",
+		"  --port <int>    - listen port (required)",
+	)
+}
+
+func TestPositionalUsage(t *testing.T) {
+
+	type CopyConfig struct {
+		Force   bool     `flag:"force" description:"overwrite"`
+		Dest    string   `flag:",arg0" description:"destination"`
+		Sources []string `flag:",arg1-" optional:"true" description:"sources"`
+	}
+
+	cc := NewCommand(func(ctx context.Context, cfg CopyConfig) error {
+		return nil
+	})
+
+	if got := cc.usage(); got != "<arg0> [<arg1...>] [options]" {
+		t.Errorf("Unexpected usage %q", got)
+	}
+
+	compareLines(t, cc.Help(),
+		"",
+		"  --force - overwrite",
+		"Args:",
+		"  <arg0>    - destination",
+		"  <arg1...> - sources",
+	)
+
+	type RemainingConfig struct {
+		Name string   `flag:",arg0" description:"name"`
+		Rest []string `flag:",remaining" description:"passed through"`
+	}
+
+	rc := NewCommand(func(ctx context.Context, cfg RemainingConfig) error {
+		return nil
+	})
+	if got := rc.usage(); got != "<arg0> [remaining...] [options]" {
+		t.Errorf("Unexpected usage %q", got)
+	}
+
+	type ProxyConfig struct {
+		Target string   `flag:",arg0" description:"target"`
+		Args   []string `flag:",passthrough" description:"passed to the target"`
+	}
+
+	pc := NewCommand(func(ctx context.Context, cfg ProxyConfig) error {
+		return nil
+	})
+	if got := pc.usage(); got != "<arg0> [args...] [options]" {
+		t.Errorf("Unexpected usage %q", got)
+	}
+	compareLines(t, pc.Help(),
+		"",
+		"Args:",
+		"  <arg0>             - target",
+		"  <passthrough args> - passed to the target",
+	)
+}
+
+func TestMissingPositionalHelp(t *testing.T) {
+
+	type ArgConfig struct {
+		Name string `flag:",arg0" description:"the name"`
+	}
+
+	root := NewCommandSet()
+	root.Add("greet", NewCommand(func(ctx context.Context, cfg ArgConfig) error {
+		return nil
+	}))
+
+	capture := &bytes.Buffer{}
+	root.runMain(context.Background(), capture, capture, []string{"test", "greet"})
+	compareLines(t, capture.String(),
+		"Usage: test greet <arg0> [options]",
+		"  <arg0> : required, got 0 of 1 required args",
+		"Args:",
+		"  <arg0> - the name",
+		"",
+	)
+}
+
+func TestRequiredHelp(t *testing.T) {
+
+	type RequiredConfig struct {
+		Name    string `flag:"name" description:"required name"`
+		Region  string `flag:"region" default:"eu" description:"has a default"`
+		Comment string `flag:"comment" optional:"true" description:"optional"`
+		Verbose bool   `flag:"verbose" description:"plain bool"`
+		Enabled *bool  `flag:"enabled" description:"pointer bool"`
+	}
+
+	cc := NewCommand(func(ctx context.Context, cfg RequiredConfig) error {
+		return nil
+	})
+
+	compareLines(t, cc.Help(),
+		"",
+		"  --name <string>    - required name (required)",
+		"  --region <string>  - has a default (default: eu)",
+		"  --comment <string> - optional",
+		"  --verbose          - plain bool",
+		"  --enabled          - pointer bool (required)",
+	)
+}
+
+func TestArgFallbackHelp(t *testing.T) {
+
+	type CatConfig struct {
+		File string `flag:"file" arg:"0" description:"file to print"`
+	}
+
+	cc := NewCommand(func(ctx context.Context, cfg CatConfig) error {
+		return nil
+	}, WithHelpWidth(defaultHelpWidth))
+
+	if got := cc.usage(); got != "[<arg0>] [options]" {
+		t.Errorf("Expected usage %q, got %q", "[<arg0>] [options]", got)
+	}
+
+	compareLines(t, cc.Help(),
+		"",
+		"  --file <string> - file to print (required)",
+		"Args:",
+		"  <arg0> - file to print (or --file)",
+	)
+
+	type EnvArgConfig struct {
+		Target string `flag:",arg0" env:"TARGET" description:"deploy target"`
+	}
+
+	envCmd := NewCommand(func(ctx context.Context, cfg EnvArgConfig) error {
+		return nil
+	}, WithHelpWidth(defaultHelpWidth))
+
+	if got := envCmd.usage(); got != "[<arg0>] [options]" {
+		t.Errorf("Expected usage %q, got %q", "[<arg0>] [options]", got)
+	}
+
+	compareLines(t, envCmd.Help(),
+		"",
+		"Args:",
+		"  <arg0> - deploy target (or $TARGET)",
+	)
+}
+
+type modeEnum string
+
+func (me *modeEnum) FromRunnerString(val string) error {
+	*me = modeEnum(val)
+	return nil
+}
+
+func (me modeEnum) EnumValues() []string {
+	return []string{"fast", "safe"}
+}
+
+func TestEnumHelp(t *testing.T) {
+
+	type EnumConfig struct {
+		Mode modeEnum `flag:"mode" default:"safe" description:"run mode"`
+	}
+
+	cc := NewCommand(func(ctx context.Context, cfg EnumConfig) error {
+		return nil
+	}, WithHelpWidth(defaultHelpWidth))
+
+	compareLines(t, cc.Help(),
+		"",
+		"  --mode <value> - run mode (one of: fast, safe) (default: safe)",
+	)
+}
+
+func TestNoOptionsCommand(t *testing.T) {
+
+	ran := false
+	root := NewCommandSet()
+	root.Add("ping", NewCommand(func(ctx context.Context, cfg struct{}) error {
+		ran = true
+		return nil
+	}))
+
+	for _, args := range [][]string{
+		{"ping", "--foo"},
+		{"ping", "--foo=bar"},
+		{"ping", "extra"},
+	} {
+		err := root.Run(context.Background(), args)
+		if !errors.Is(err, cliconf.ErrNoOptions) {
+			t.Errorf("%v: Expected ErrNoOptions, got %v", args, err)
+		}
+	}
+	if ran {
+		t.Errorf("Command should not run with options")
+	}
+
+	capture := &bytes.Buffer{}
+	root.runMain(context.Background(), capture, capture, []string{"test", "ping", "--foo"})
+	compareLines(t, capture.String(),
+		"Usage: test ping",
+		`  this command takes no options, got ["--foo"]`,
+		"",
+	)
+
+	if err := root.Run(context.Background(), []string{"ping"}); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+	if !ran {
+		t.Errorf("Command should run without options")
+	}
+}
+
+func TestPrintConfig(t *testing.T) {
+
+	type PrintConfig struct {
+		Name  string `flag:"name"`
+		Port  int    `flag:"port" default:"8080"`
+		Token string `flag:"token" secret:"true" default:"abc"`
+	}
+
+	ran := false
+	cc := NewCommand(func(ctx context.Context, cfg PrintConfig) error {
+		ran = true
+		return nil
+	}, WithPrintConfig())
+
+	out := &bytes.Buffer{}
+	ctx := context.WithValue(context.Background(), stdoutKey{}, out)
+	if err := cc.Run(ctx, []string{"--print-config", "--name=svc"}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if ran {
+		t.Error("Expected the callback not to run")
+	}
+	compareLines(t, out.String(),
+		"Name=svc # flag",
+		"Port=8080 # default",
+		"Token=**** # default",
+		"",
+	)
+
+	t.Run("not enabled", func(t *testing.T) {
+		plain := NewCommand(func(ctx context.Context, cfg PrintConfig) error {
+			return nil
+		})
+		err := plain.Run(context.Background(), []string{"--print-config", "--name=svc"})
+		if err == nil {
+			t.Error("Expected --print-config to be an unknown flag")
+		}
+	})
+
+	t.Run("passthrough", func(t *testing.T) {
+		type ProxyConfig struct {
+			Target string   `flag:",arg0"`
+			Args   []string `flag:",passthrough"`
+		}
+		var got []string
+		proxy := NewCommand(func(ctx context.Context, cfg ProxyConfig) error {
+			got = cfg.Args
+			return nil
+		}, WithPrintConfig())
+		out := &bytes.Buffer{}
+		ctx := context.WithValue(context.Background(), stdoutKey{}, out)
+		if err := proxy.Run(ctx, []string{"kubectl", "config", "--print-config"}); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if out.Len() != 0 {
+			t.Errorf("Expected the config not to be printed, got %q", out.String())
+		}
+		if want := []string{"config", "--print-config"}; !reflect.DeepEqual(got, want) {
+			t.Errorf("Expected args %q, got %q", want, got)
+		}
+	})
+}
+
+type rangeConfig struct {
+	From int    `flag:"from"`
+	To   int    `flag:"to"`
+	Unit string `flag:"unit" default:"Seconds"`
+}
+
+func (rc *rangeConfig) Normalize() {
+	rc.Unit = strings.ToLower(rc.Unit)
+}
+
+func (rc *rangeConfig) Validate() error {
+	if rc.From > rc.To {
+		return fmt.Errorf("--from %d is after --to %d", rc.From, rc.To)
+	}
+	return nil
+}
+
+func TestConfigHooks(t *testing.T) {
+
+	var got rangeConfig
+	cc := NewCommand(func(ctx context.Context, cfg rangeConfig) error {
+		got = cfg
+		return nil
+	})
+
+	if err := cc.Run(context.Background(), []string{"--from=1", "--to=2"}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if got.Unit != "seconds" {
+		t.Errorf("Expected Normalize to run before the callback, got %q", got.Unit)
+	}
+
+	got = rangeConfig{}
+	err := cc.Run(context.Background(), []string{"--from=3", "--to=2"})
+	helpError := HelpError{}
+	if !errors.As(err, &helpError) {
+		t.Fatalf("Expected HelpError, got %v", err)
+	}
+	if helpError.Unwrap() == nil || helpError.Unwrap().Error() != "--from 3 is after --to 2" {
+		t.Errorf("Expected the Validate error to be wrapped, got %v", helpError.Unwrap())
+	}
+	if len(helpError.Lines) == 0 || helpError.Lines[0] != "  --from 3 is after --to 2" {
+		t.Errorf("Expected the Validate error first in the help lines, got %q", helpError.Lines)
+	}
+	if got.From != 0 {
+		t.Errorf("Expected the callback not to run")
+	}
+}
+
+type contextLookup string
+
+func (cl *contextLookup) FromRunnerStringContext(ctx context.Context, key string) error {
+	val, _ := ctx.Value(testContextKey{}).(string)
+	*cl = contextLookup(key + "=" + val)
+	return nil
+}
+
+func TestContextSetterCommand(t *testing.T) {
+
+	type LookupConfig struct {
+		Value contextLookup `flag:"value"`
+	}
+
+	var got contextLookup
+	cc := NewCommand(func(ctx context.Context, cfg LookupConfig) error {
+		got = cfg.Value
+		return nil
+	})
+
+	ctx := context.WithValue(context.Background(), testContextKey{}, "from-context")
+	if err := cc.Run(ctx, []string{"--value=key"}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if got != "key=from-context" {
+		t.Errorf("Expected the setter to get the command's context, got %q", got)
+	}
+}
@@ -0,0 +1,65 @@
+package commander
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/pentops/log.go/log"
+)
+
+// LogConfig is embedded in command configs to give consistent logging flags
+// across commands. Call Apply at the start of the command to configure the
+// default logger.
+type LogConfig struct {
+	LogLevel  string `flag:"log-level" env:"LOG_LEVEL" default:"info" group:"Logging" description:"debug, info, warn or error"`
+	LogFormat string `flag:"log-format" env:"LOG_FORMAT" default:"json" group:"Logging" description:"json, or pretty for human readable output"`
+}
+
+// Apply replaces log.DefaultLogger with a logger writing to os.Stderr in the
+// configured format and level.
+func (lc LogConfig) Apply() error {
+	logger, err := lc.newLogger(os.Stderr)
+	if err != nil {
+		return err
+	}
+	log.DefaultLogger = logger
+	return nil
+}
+
+func (lc LogConfig) newLogger(out io.Writer) (log.Logger, error) {
+	level, err := parseLogLevel(lc.LogLevel)
+	if err != nil {
+		return nil, err
+	}
+
+	var formatter log.LogFunc
+	switch strings.ToLower(lc.LogFormat) {
+	case "", "json":
+		formatter = log.JSONLog(out)
+	case "pretty", "text":
+		formatter = log.PrettyLog(out, log.SkipFields("version", "app"))
+	default:
+		return nil, fmt.Errorf("invalid log format %q, expected json or pretty", lc.LogFormat)
+	}
+
+	logger := log.NewCallbackLogger(formatter)
+	logger.SetLevel(level)
+	return logger, nil
+}
+
+func parseLogLevel(level string) (slog.Level, error) {
+	switch strings.ToLower(level) {
+	case "debug":
+		return slog.LevelDebug, nil
+	case "", "info":
+		return slog.LevelInfo, nil
+	case "warn", "warning":
+		return slog.LevelWarn, nil
+	case "error":
+		return slog.LevelError, nil
+	}
+	return slog.LevelInfo, fmt.Errorf("invalid log level %q, expected debug, info, warn or error", level)
+}
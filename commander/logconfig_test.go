@@ -0,0 +1,88 @@
+package commander
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestParseLogLevel(t *testing.T) {
+
+	for _, tc := range []struct {
+		input     string
+		expected  slog.Level
+		expectErr bool
+	}{
+		{input: "debug", expected: slog.LevelDebug},
+		{input: "DEBUG", expected: slog.LevelDebug},
+		{input: "info", expected: slog.LevelInfo},
+		{input: "", expected: slog.LevelInfo},
+		{input: "warn", expected: slog.LevelWarn},
+		{input: "warning", expected: slog.LevelWarn},
+		{input: "error", expected: slog.LevelError},
+		{input: "verbose", expectErr: true},
+		{input: "trace", expectErr: true},
+	} {
+		t.Run(tc.input, func(t *testing.T) {
+			got, err := parseLogLevel(tc.input)
+			if tc.expectErr {
+				if err == nil {
+					t.Fatalf("Expected error, got %v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if got != tc.expected {
+				t.Errorf("Expected %v, got %v", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestLogConfig(t *testing.T) {
+
+	type ServeConfig struct {
+		LogConfig
+		Port int `flag:"port" default:"8080"`
+	}
+
+	var gotConfig ServeConfig
+	cc := NewCommand(func(ctx context.Context, cfg ServeConfig) error {
+		gotConfig = cfg
+		return nil
+	})
+
+	if err := cc.Run(context.Background(), []string{"--log-level", "warn"}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if gotConfig.LogLevel != "warn" || gotConfig.LogFormat != "json" {
+		t.Errorf("Unexpected log config %+v", gotConfig.LogConfig)
+	}
+
+	out := &bytes.Buffer{}
+	logger, err := gotConfig.newLogger(out)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	logger.Info(context.Background(), "hidden")
+	logger.Warn(context.Background(), "shown")
+	if strings.Contains(out.String(), "hidden") {
+		t.Errorf("Expected info to be filtered at warn level, got %q", out.String())
+	}
+	if !strings.Contains(out.String(), "shown") {
+		t.Errorf("Expected warn to be logged, got %q", out.String())
+	}
+
+	invalid := LogConfig{LogLevel: "loud"}
+	if err := invalid.Apply(); err == nil {
+		t.Errorf("Expected error for invalid level")
+	}
+	invalid = LogConfig{LogLevel: "info", LogFormat: "xml"}
+	if err := invalid.Apply(); err == nil {
+		t.Errorf("Expected error for invalid format")
+	}
+}
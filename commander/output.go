@@ -0,0 +1,28 @@
+package commander
+
+import (
+	"context"
+	"io"
+	"os"
+)
+
+type stdoutKey struct{}
+type stderrKey struct{}
+
+// Stdout returns the writer commands should print output to, set with
+// CommandSet.WithStdout or WithOutputWriter, defaulting to os.Stdout.
+func Stdout(ctx context.Context) io.Writer {
+	if out, ok := ctx.Value(stdoutKey{}).(io.Writer); ok {
+		return out
+	}
+	return os.Stdout
+}
+
+// Stderr returns the writer commands should print diagnostics to, set with
+// CommandSet.WithStderr or WithErrorWriter, defaulting to os.Stderr.
+func Stderr(ctx context.Context) io.Writer {
+	if out, ok := ctx.Value(stderrKey{}).(io.Writer); ok {
+		return out
+	}
+	return os.Stderr
+}
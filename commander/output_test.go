@@ -0,0 +1,57 @@
+package commander
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"testing"
+)
+
+func TestCommandOutput(t *testing.T) {
+
+	version := NewCommand(func(ctx context.Context, cfg struct{}) error {
+		fmt.Fprintln(Stdout(ctx), "v1.2.3")
+		fmt.Fprintln(Stderr(ctx), "checking for updates")
+		return nil
+	})
+
+	t.Run("command set", func(t *testing.T) {
+		stdout := &bytes.Buffer{}
+		stderr := &bytes.Buffer{}
+		root := NewCommandSet()
+		root.WithStdout(stdout)
+		root.WithStderr(stderr)
+		root.Add("version", version)
+
+		if err := root.Run(context.Background(), []string{"version"}); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		compareLines(t, stdout.String(), "v1.2.3", "")
+		compareLines(t, stderr.String(), "checking for updates", "")
+	})
+
+	t.Run("run main", func(t *testing.T) {
+		stdout := &bytes.Buffer{}
+		stderr := &bytes.Buffer{}
+		root := NewCommandSet()
+		root.Add("version", version)
+
+		err := root.RunMainErr("test", "1.0",
+			WithArgs([]string{"test", "version"}),
+			WithOutputWriter(stdout),
+			WithErrorWriter(stderr),
+		)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		compareLines(t, stdout.String(), "v1.2.3", "")
+		compareLines(t, stderr.String(), "checking for updates", "")
+	})
+
+	t.Run("default", func(t *testing.T) {
+		if Stdout(context.Background()) != os.Stdout {
+			t.Error("Expected os.Stdout without an injected writer")
+		}
+	})
+}
@@ -0,0 +1,57 @@
+package commander
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/mattn/go-isatty"
+	"github.com/pentops/runner/cliconf"
+)
+
+// WithInteractive prompts for required flags which have no value, rather than
+// failing, when stdin is a terminal. Secret fields are read without echo. When
+// stdin is not a terminal the missing fields are reported as usual.
+func WithInteractive() func(*CommandOption) {
+	return func(co *CommandOption) {
+		co.interactive = true
+	}
+}
+
+func stdinIsTerminal() bool {
+	fd := os.Stdin.Fd()
+	return isatty.IsTerminal(fd) || isatty.IsCygwinTerminal(fd)
+}
+
+// terminalPrompt prompts on out and reads each answer as a line from in.
+// readSecret reads a line without echo, it is nil where that isn't supported,
+// in which case secret fields are not prompted for.
+func terminalPrompt(in *bufio.Reader, out io.Writer, readSecret func(*bufio.Reader) (string, error)) func(cliconf.PromptField) (string, bool) {
+	return func(field cliconf.PromptField) (string, bool) {
+		if field.Secret && readSecret == nil {
+			return "", false
+		}
+
+		if field.Description != "" {
+			fmt.Fprintf(out, "%s (%s): ", field.Name, field.Description)
+		} else {
+			fmt.Fprintf(out, "%s: ", field.Name)
+		}
+
+		var line string
+		var err error
+		if field.Secret {
+			line, err = readSecret(in)
+			// the newline typed by the user isn't echoed
+			fmt.Fprintln(out)
+		} else {
+			line, err = in.ReadString('\n')
+		}
+		if err != nil && line == "" {
+			return "", false
+		}
+		return strings.TrimRight(line, "\r\n"), true
+	}
+}
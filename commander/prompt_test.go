@@ -0,0 +1,44 @@
+package commander
+
+import (
+	"bufio"
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/pentops/runner/cliconf"
+)
+
+func TestTerminalPrompt(t *testing.T) {
+
+	in := bufio.NewReader(strings.NewReader("foo-value\nsecret-value\n"))
+	out := &bytes.Buffer{}
+	readSecret := func(in *bufio.Reader) (string, error) {
+		return in.ReadString('\n')
+	}
+
+	prompt := terminalPrompt(in, out, readSecret)
+
+	val, ok := prompt(cliconf.PromptField{Name: "--foo", Description: "foo description"})
+	if !ok || val != "foo-value" {
+		t.Errorf("Expected foo-value, got %q %v", val, ok)
+	}
+
+	val, ok = prompt(cliconf.PromptField{Name: "--password", Secret: true})
+	if !ok || val != "secret-value" {
+		t.Errorf("Expected secret-value, got %q %v", val, ok)
+	}
+
+	if out.String() != "--foo (foo description): --password: \n" {
+		t.Errorf("Unexpected prompt output %q", out.String())
+	}
+
+	if _, ok := prompt(cliconf.PromptField{Name: "--bar"}); ok {
+		t.Errorf("Expected no value at end of input")
+	}
+
+	noSecret := terminalPrompt(in, out, nil)
+	if _, ok := noSecret(cliconf.PromptField{Name: "--password", Secret: true}); ok {
+		t.Errorf("Expected secret fields to be skipped without no-echo support")
+	}
+}
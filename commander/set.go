@@ -20,13 +20,18 @@ type Runnable interface {
 }
 
 type CommandSet struct {
-	commands []namedRunnable
+	commands          []namedRunnable
+	contextDecorators []func(context.Context) context.Context
+	stdout            io.Writer
+	stderr            io.Writer
+	versionFunc       func() string
 }
 
 type namedRunnable struct {
 	name        string
 	command     Runnable
 	description string
+	category    string
 }
 
 func NewCommandSet() *CommandSet {
@@ -39,6 +44,15 @@ func CommandWithDescription(description string) func(*namedRunnable) {
 	}
 }
 
+// CommandWithCategory lists the command under a category heading in help.
+// Categories are listed in the order they are first used, followed by any
+// commands without a category.
+func CommandWithCategory(category string) func(*namedRunnable) {
+	return func(nr *namedRunnable) {
+		nr.category = category
+	}
+}
+
 func (cs *CommandSet) Add(name string, command Runnable, options ...func(*namedRunnable)) {
 	nr := namedRunnable{
 		name:        name,
@@ -53,6 +67,71 @@ func (cs *CommandSet) Add(name string, command Runnable, options ...func(*namedR
 	cs.commands = append(cs.commands, nr)
 }
 
+// WithContextDecorator registers a function to attach shared dependencies,
+// e.g. a logger or database handle, to the context passed to commands.
+// Decorators run in the order they are registered, each time a command in the
+// set is dispatched, after the command has been selected and before its Run
+// method is called. Any hooks the command itself runs therefore already see
+// the decorated context. Decorators of parent sets run before those of nested
+// sets.
+func (cs *CommandSet) WithContextDecorator(decorator func(context.Context) context.Context) {
+	cs.contextDecorators = append(cs.contextDecorators, decorator)
+}
+
+// WithStdout sets the writer returned by Stdout(ctx) for commands in the set,
+// overriding the writer of any parent set or RunMain.
+func (cs *CommandSet) WithStdout(stdout io.Writer) {
+	cs.stdout = stdout
+}
+
+// WithStderr sets the writer returned by Stderr(ctx) for commands in the set,
+// overriding the writer of any parent set or RunMain.
+func (cs *CommandSet) WithStderr(stderr io.Writer) {
+	cs.stderr = stderr
+}
+
+// WithVersionFunc sets a function returning the text printed for `version`
+// or `--version` by RunMain, e.g. to include the commit and build date from
+// ldflags. It is only called when the version is printed. Without it, the
+// name and version passed to RunMain are printed.
+func (cs *CommandSet) WithVersionFunc(versionFunc func() string) {
+	cs.versionFunc = versionFunc
+}
+
+// isVersionArg is true for the args RunMain answers with the version. A
+// command named version in the set takes precedence.
+func (cs *CommandSet) isVersionArg(arg string) bool {
+	if arg == "--version" {
+		return true
+	}
+	if arg != "version" {
+		return false
+	}
+	_, ok := cs.findCommand(arg)
+	return !ok
+}
+
+func (cs *CommandSet) printVersion(out io.Writer, name, version string) {
+	if cs.versionFunc != nil {
+		fmt.Fprintln(out, strings.TrimSuffix(cs.versionFunc(), "\n"))
+		return
+	}
+	fmt.Fprintf(out, "%s %s\n", name, version)
+}
+
+func (cs *CommandSet) decorateContext(ctx context.Context) context.Context {
+	if cs.stdout != nil {
+		ctx = context.WithValue(ctx, stdoutKey{}, cs.stdout)
+	}
+	if cs.stderr != nil {
+		ctx = context.WithValue(ctx, stderrKey{}, cs.stderr)
+	}
+	for _, decorator := range cs.contextDecorators {
+		ctx = decorator(ctx)
+	}
+	return ctx
+}
+
 type commandDescriptor interface {
 	CommandDescriptions() [][]string
 }
@@ -60,12 +139,19 @@ type commandDescriptor interface {
 func (cs *CommandSet) CommandDescriptions() [][]string {
 	descriptions := make([][]string, 0, len(cs.commands))
 	for _, command := range cs.commands {
-		descriptions = append(descriptions, []string{command.name, command.description})
-		if wd, ok := command.command.(commandDescriptor); ok {
-			for _, subCommand := range wd.CommandDescriptions() {
-				subCommand[0] = " | " + subCommand[0]
-				descriptions = append(descriptions, subCommand)
-			}
+		descriptions = append(descriptions, command.descriptions()...)
+	}
+	return descriptions
+}
+
+// descriptions returns the command's own line, followed by any nested
+// commands
+func (nr namedRunnable) descriptions() [][]string {
+	descriptions := [][]string{{nr.name, nr.description}}
+	if wd, ok := nr.command.(commandDescriptor); ok {
+		for _, subCommand := range wd.CommandDescriptions() {
+			subCommand[0] = " | " + subCommand[0]
+			descriptions = append(descriptions, subCommand)
 		}
 	}
 	return descriptions
@@ -86,52 +172,267 @@ func (cs *CommandSet) printCommands(out io.Writer, prefix string) {
 	}
 }
 
+// uncategorized is the heading for commands without a category, when other
+// commands have one
+const uncategorized = "Other"
+
 func (cs *CommandSet) listCommands(prefix string) []string {
-	lines := cs.CommandDescriptions()
-	return evenJoin(prefix, lines)
+	categoryNames := []string{}
+	categories := map[string][][]string{}
+	for _, command := range cs.commands {
+		if _, ok := categories[command.category]; !ok && command.category != "" {
+			categoryNames = append(categoryNames, command.category)
+		}
+		categories[command.category] = append(categories[command.category], command.descriptions()...)
+	}
+
+	if len(categoryNames) == 0 {
+		return evenJoin(prefix, terminalWidth(), categories[""])
+	}
+
+	if _, ok := categories[""]; ok {
+		categoryNames = append(categoryNames, uncategorized)
+		categories[uncategorized] = append(categories[uncategorized], categories[""]...)
+	}
+
+	// Pad the names so that every category aligns to the same column
+	maxLen := 0
+	for _, name := range categoryNames {
+		for _, line := range categories[name] {
+			maxLen = max(maxLen, len(line[0]))
+		}
+	}
+
+	out := []string{}
+	for _, name := range categoryNames {
+		lines := categories[name]
+		for _, line := range lines {
+			line[0] = fmt.Sprintf("%-*s", maxLen, line[0])
+		}
+		out = append(out, prefix+name+":")
+		out = append(out, evenJoin(prefix+"  ", terminalWidth(), lines)...)
+	}
+	return out
 }
 
-func evenJoin(prefix string, lines [][]string) []string {
+// defaultHelpWidth is the width help output is wrapped to when the terminal
+// width is not known
+const defaultHelpWidth = 80
+
+// minWrapWidth is the narrowest description column which will be wrapped,
+// anything narrower is left on one line as wrapping would be unreadable.
+const minWrapWidth = 20
+
+// evenJoin aligns the descriptions of each line into a column, wrapping
+// descriptions which would exceed width onto continuation lines indented to
+// the same column. A width of 0 disables wrapping.
+func evenJoin(prefix string, width int, lines [][]string) []string {
 	maxLen := 0
 	for _, command := range lines {
 		if len(command[0]) > maxLen {
 			maxLen = len(command[0])
 		}
 	}
-	linesOut := make([]string, len(lines))
+	linesOut := make([]string, 0, len(lines))
 
-	for idx, command := range lines {
-		linesOut[idx] = fmt.Sprintf(prefix+"%-*s - %s", maxLen, command[0], strings.Join(command[1:], "  "))
+	descriptionColumn := len(prefix) + maxLen + len(" - ")
+	descriptionWidth := width - descriptionColumn
+	indent := strings.Repeat(" ", descriptionColumn)
+
+	for _, command := range lines {
+		description := strings.Join(command[1:], "  ")
+		wrapped := []string{description}
+		if width > 0 && descriptionWidth >= minWrapWidth {
+			wrapped = wrapText(description, descriptionWidth)
+		}
+		linesOut = append(linesOut, fmt.Sprintf(prefix+"%-*s - %s", maxLen, command[0], wrapped[0]))
+		for _, continuation := range wrapped[1:] {
+			linesOut = append(linesOut, indent+continuation)
+		}
 	}
 	return linesOut
 }
 
+// wrapText splits text into lines of at most width, breaking on spaces. Words
+// longer than width are left on their own line.
+func wrapText(text string, width int) []string {
+	words := strings.Fields(text)
+	if len(words) == 0 {
+		return []string{text}
+	}
+
+	lines := make([]string, 0, 1)
+	current := words[0]
+	for _, word := range words[1:] {
+		if len(current)+1+len(word) > width {
+			lines = append(lines, current)
+			current = word
+			continue
+		}
+		current += " " + word
+	}
+	return append(lines, current)
+}
+
+type runMainOptions struct {
+	args          []string
+	out           io.Writer
+	errOut        io.Writer
+	forceExitCode int
+
+	// signals and exit are replaced in tests
+	signals chan os.Signal
+	exit    func(int)
+}
+
+// DefaultForceExitCode is the exit code used when a second signal is received
+// while the command is stopping, matching the shell convention for SIGINT.
+const DefaultForceExitCode = 130
+
+// RunMainOption configures RunMain and RunMainErr
+type RunMainOption func(*runMainOptions)
+
+// WithArgs overrides os.Args. As with os.Args, the first element is the
+// program name.
+func WithArgs(args []string) RunMainOption {
+	return func(rmo *runMainOptions) {
+		rmo.args = args
+	}
+}
+
+// WithErrorWriter sets where usage and errors are written, and the writer
+// returned by Stderr(ctx), defaults to os.Stderr.
+func WithErrorWriter(errOut io.Writer) RunMainOption {
+	return func(rmo *runMainOptions) {
+		rmo.errOut = errOut
+	}
+}
+
+// WithOutputWriter sets where completion suggestions, versions and requested
+// help are written, and the writer returned by Stdout(ctx), defaults to
+// os.Stdout.
+func WithOutputWriter(out io.Writer) RunMainOption {
+	return func(rmo *runMainOptions) {
+		rmo.out = out
+	}
+}
+
+// WithForceExitCode sets the exit code used when a second interrupt or
+// SIGTERM is received while the command is stopping. Defaults to
+// DefaultForceExitCode.
+func WithForceExitCode(code int) RunMainOption {
+	return func(rmo *runMainOptions) {
+		rmo.forceExitCode = code
+	}
+}
+
 // RunMain should run from the main command, it will handle OS Exits, and should
 // be the only goroutine running.
-func (cs *CommandSet) RunMain(name, version string) {
+func (cs *CommandSet) RunMain(name, version string, options ...RunMainOption) {
+	err := cs.RunMainErr(name, version, options...)
+	if err != nil {
+		os.Exit(1)
+	}
+}
+
+// RunMainErr runs the command in the same way as RunMain, printing usage and
+// errors, but returns the error rather than exiting.
+// The context passed to the command is canceled on the first interrupt or
+// SIGTERM, a second signal exits immediately with the force exit code, so a
+// hung shutdown can be escaped. The hidden CompleteCommand, and `version` or
+// `--version` on their own, are answered without running any command. Help
+// asked for with `--help` or `-h` is printed to stdout and is not an error.
+func (cs *CommandSet) RunMainErr(name, version string, options ...RunMainOption) error {
+	opts := &runMainOptions{
+		args:          os.Args,
+		out:           os.Stdout,
+		errOut:        os.Stderr,
+		forceExitCode: DefaultForceExitCode,
+		exit:          os.Exit,
+	}
+	for _, option := range options {
+		option(opts)
+	}
+
+	if len(opts.args) > 1 && opts.args[1] == CompleteCommand {
+		for _, suggestion := range cs.Complete(opts.args[2:]) {
+			fmt.Fprintln(opts.out, suggestion)
+		}
+		return nil
+	}
+
+	if len(opts.args) == 2 && cs.isVersionArg(opts.args[1]) {
+		cs.printVersion(opts.out, name, version)
+		return nil
+	}
+
 	ctx := context.Background()
+	ctx = context.WithValue(ctx, stdoutKey{}, opts.out)
+	ctx = context.WithValue(ctx, stderrKey{}, opts.errOut)
 	ctx = log.WithFields(ctx, map[string]interface{}{
 		"app":     name,
 		"version": version,
 	})
-	ctx, stop := signal.NotifyContext(ctx,
-		os.Interrupt,
-		os.Kill,
-		os.Signal(syscall.SIGTERM),
-	)
-
-	ok := cs.runMain(ctx, os.Stderr, os.Args)
-	stop()
-	if !ok {
-		os.Exit(1)
+	ctx, stop := watchSignals(ctx, opts)
+	defer stop()
+
+	return cs.runMain(ctx, opts.out, opts.errOut, opts.args)
+}
+
+// watchSignals returns a context which is canceled on the first signal, a
+// second signal exits the process. The returned func stops watching.
+func watchSignals(ctx context.Context, opts *runMainOptions) (context.Context, func()) {
+	ctx, cancel := context.WithCancel(ctx)
+
+	signals := opts.signals
+	if signals == nil {
+		signals = make(chan os.Signal, 2)
+		signal.Notify(signals,
+			os.Interrupt,
+			os.Kill,
+			os.Signal(syscall.SIGTERM),
+		)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		select {
+		case <-signals:
+			cancel()
+		case <-done:
+			return
+		}
+
+		select {
+		case sig := <-signals:
+			fmt.Fprintf(opts.errOut, "Received %s while stopping, exiting\n", sig)
+			opts.exit(opts.forceExitCode)
+		case <-done:
+		}
+	}()
+
+	return ctx, func() {
+		signal.Stop(signals)
+		close(done)
+		cancel()
 	}
 }
 
-func (cs *CommandSet) runMain(ctx context.Context, errOut io.Writer, args []string) bool {
+// runMain prints usage and errors to errOut, help which was asked for with
+// `--help` goes to out instead, and is not an error.
+func (cs *CommandSet) runMain(ctx context.Context, out, errOut io.Writer, args []string) error {
+	if len(args) == 2 && isHelpArg(args[1]) {
+		term := detectTerminal(out)
+		fmt.Fprintf(out, "%s %s <command> [options]\n", term.bold("Usage:"), args[0])
+		cs.printCommands(out, "  ")
+		return nil
+	}
+
+	term := detectTerminal(errOut)
 	if len(args) < 2 {
-		fmt.Fprintf(errOut, "Usage: %s <command> [options]\n", args[0])
+		fmt.Fprintf(errOut, "%s %s <command> [options]\n", term.bold("Usage:"), args[0])
 		cs.printCommands(errOut, "  ")
-		return false
+		return ErrNoCommand
 	}
 
 	commandName := args[1]
@@ -139,49 +440,98 @@ func (cs *CommandSet) runMain(ctx context.Context, errOut io.Writer, args []stri
 	if !ok {
 		fmt.Fprintf(errOut, "Unknown command: '%s'\n", commandName)
 		cs.printCommands(errOut, "  ")
-		return false
+		return UnknownCommandError{Name: commandName}
 	}
 
+	ctx = cs.decorateContext(ctx)
 	mainErr := command.command.Run(ctx, args[2:])
 	if mainErr != nil {
+		if unknownCommand := new(UnknownCommandError); errors.As(mainErr, unknownCommand) {
+			fmt.Fprintf(errOut, "Unknown command: '%s'\n", unknownCommand.Name)
+			helpError := new(HelpError)
+			if errors.As(mainErr, helpError) {
+				for _, line := range helpError.Lines {
+					fmt.Fprintf(errOut, "%s\n", line)
+				}
+			}
+			return mainErr
+		}
 		if helpError := new(HelpError); errors.As(mainErr, helpError) {
-			fmt.Fprintf(errOut, "Usage: %s %s %s\n", args[0], args[1], helpError.Usage)
+			helpOut := errOut
+			if errors.Is(mainErr, ErrHelpRequested) {
+				helpOut = out
+				term = detectTerminal(out)
+			}
+			fmt.Fprintln(helpOut, strings.TrimSpace(fmt.Sprintf("%s %s %s %s", term.bold("Usage:"), args[0], args[1], helpError.Usage)))
 			for _, line := range helpError.Lines {
-				fmt.Fprintf(errOut, "%s\n", line)
+				fmt.Fprintf(helpOut, "%s\n", line)
+			}
+			if helpOut == out {
+				return nil
 			}
-			return false
+			return mainErr
 		}
 		if flagErr := new(cliconf.FlagError); errors.As(mainErr, flagErr) {
 			flagErrString := strings.Replace(flagErr.Error(), "$0", strings.Join(args[0:2], " "), -1)
 			fmt.Fprintln(errOut, flagErrString)
-			return false
+			return mainErr
 		}
 
 		fmt.Fprintf(errOut, "Command %q returned error\n%s\n", commandName, mainErr)
-		return false
+		return mainErr
 	}
-	return true
+	return nil
+}
+
+// ErrNoCommand is returned, wrapped in a HelpError, when a CommandSet is run
+// without a command name.
+var ErrNoCommand = errors.New("no command specified")
+
+// UnknownCommandError is returned, wrapped in a HelpError, when a CommandSet
+// is run with a command name which is not in the set.
+type UnknownCommandError struct {
+	Name string
+}
+
+func (uce UnknownCommandError) Error() string {
+	return fmt.Sprintf("unknown command: '%s'", uce.Name)
 }
 
+// Run runs the named command from the first arg. When the command is missing
+// or unknown the returned HelpError wraps ErrNoCommand or an
+// UnknownCommandError, so callers can distinguish them with errors.Is and
+// errors.As.
 func (cs *CommandSet) Run(ctx context.Context, args []string) error {
 	if len(args) == 0 {
 		return HelpError{
 			Usage: "<command> [options]",
 			Lines: cs.listCommands("  "),
+			Err:   ErrNoCommand,
+		}
+	}
+
+	if isHelpArg(args[0]) {
+		return HelpError{
+			Usage: "<command> [options]",
+			Lines: cs.listCommands("  "),
+			Err:   ErrHelpRequested,
 		}
 	}
 
 	command, ok := cs.findCommand(args[0])
 	if !ok {
 		return HelpError{
+			Usage: "<command> [options]",
 			Lines: cs.listCommands("  "),
+			Err:   UnknownCommandError{Name: args[0]},
 		}
 	}
 
+	ctx = cs.decorateContext(ctx)
 	mainErr := command.command.Run(ctx, args[1:])
 	if mainErr != nil {
 		if helpError := new(HelpError); errors.As(mainErr, helpError) {
-			helpError.Usage = command.name + " " + helpError.Usage
+			helpError.Usage = strings.TrimSpace(command.name + " " + helpError.Usage)
 			return *helpError
 		}
 		return mainErr
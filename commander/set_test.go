@@ -0,0 +1,385 @@
+package commander
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type testContextKey struct{}
+
+func TestContextDecorator(t *testing.T) {
+
+	var gotValue interface{}
+
+	root := NewCommandSet()
+	root.WithContextDecorator(func(ctx context.Context) context.Context {
+		return context.WithValue(ctx, testContextKey{}, "decorated")
+	})
+	root.Add("name", NewCommand(func(ctx context.Context, cfg TestConfig) error {
+		gotValue = ctx.Value(testContextKey{})
+		return nil
+	}))
+
+	err := root.Run(context.Background(), []string{"name", "--foo=foo"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if gotValue != "decorated" {
+		t.Errorf("Expected decorated context value, got %v", gotValue)
+	}
+}
+
+func TestRunErrors(t *testing.T) {
+
+	sub := NewCommandSet()
+	sub.Add("bar", NewCommand(func(ctx context.Context, cfg TestConfig) error {
+		return nil
+	}))
+
+	root := NewCommandSet()
+	root.Add("sub", sub)
+
+	t.Run("no command", func(t *testing.T) {
+		err := root.Run(context.Background(), []string{})
+		if !errors.Is(err, ErrNoCommand) {
+			t.Errorf("Expected ErrNoCommand, got %v", err)
+		}
+	})
+
+	t.Run("no sub command", func(t *testing.T) {
+		err := root.Run(context.Background(), []string{"sub"})
+		if !errors.Is(err, ErrNoCommand) {
+			t.Errorf("Expected ErrNoCommand, got %v", err)
+		}
+	})
+
+	t.Run("unknown command", func(t *testing.T) {
+		err := root.Run(context.Background(), []string{"sub", "baz"})
+		unknownCommand := UnknownCommandError{}
+		if !errors.As(err, &unknownCommand) {
+			t.Fatalf("Expected UnknownCommandError, got %v", err)
+		}
+		if unknownCommand.Name != "baz" {
+			t.Errorf("Expected name baz, got %q", unknownCommand.Name)
+		}
+		if errors.Is(err, ErrNoCommand) {
+			t.Errorf("Unknown command should not be ErrNoCommand")
+		}
+	})
+
+	t.Run("unknown sub command printed", func(t *testing.T) {
+		capture := &bytes.Buffer{}
+		root.runMain(context.Background(), capture, capture, []string{"test", "sub", "baz"})
+		compareLines(t, capture.String(),
+			"Unknown command: 'baz'",
+			"  bar - ",
+			"",
+		)
+	})
+}
+
+func TestRunMainErr(t *testing.T) {
+
+	root := NewCommandSet()
+	root.Add("name", NewCommand(func(ctx context.Context, cfg TestConfig) error {
+		if cfg.Foo == "fail" {
+			return errors.New("failed")
+		}
+		return nil
+	}))
+
+	capture := &bytes.Buffer{}
+	err := root.RunMainErr("test", "1.0", WithArgs([]string{"test", "name", "--foo=ok"}), WithErrorWriter(capture))
+	if err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+	if capture.Len() != 0 {
+		t.Errorf("Expected no output, got %q", capture.String())
+	}
+
+	err = root.RunMainErr("test", "1.0", WithArgs([]string{"test", "name", "--foo=fail"}), WithErrorWriter(capture))
+	if err == nil || err.Error() != "failed" {
+		t.Errorf("Expected command error, got %v", err)
+	}
+	compareLines(t, capture.String(),
+		"Command \"name\" returned error",
+		"failed",
+		"",
+	)
+
+	err = root.RunMainErr("test", "1.0", WithArgs([]string{"test"}), WithErrorWriter(&bytes.Buffer{}))
+	if !errors.Is(err, ErrNoCommand) {
+		t.Errorf("Expected ErrNoCommand, got %v", err)
+	}
+}
+
+func TestRunMainSecondSignal(t *testing.T) {
+
+	canceled := make(chan struct{})
+	release := make(chan struct{})
+	root := NewCommandSet()
+	root.Add("serve", NewCommand(func(ctx context.Context, cfg TestConfig) error {
+		<-ctx.Done()
+		close(canceled)
+		// shutdown hangs until the test releases it
+		<-release
+		return nil
+	}))
+
+	signals := make(chan os.Signal, 2)
+	exitCode := make(chan int, 1)
+	injectSignals := func(rmo *runMainOptions) {
+		rmo.signals = signals
+		rmo.exit = func(code int) {
+			exitCode <- code
+		}
+	}
+
+	capture := &bytes.Buffer{}
+	result := make(chan error)
+	go func() {
+		result <- root.RunMainErr("test", "1.0",
+			WithArgs([]string{"test", "serve", "--foo=foo"}),
+			WithErrorWriter(capture),
+			injectSignals,
+		)
+	}()
+
+	signals <- os.Interrupt
+	select {
+	case <-canceled:
+	case <-time.After(time.Second):
+		t.Fatal("Expected first signal to cancel the command")
+	}
+
+	signals <- os.Interrupt
+	select {
+	case code := <-exitCode:
+		if code != DefaultForceExitCode {
+			t.Errorf("Expected exit code %d, got %d", DefaultForceExitCode, code)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected second signal to force exit")
+	}
+
+	close(release)
+	if err := <-result; err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+	compareLines(t, capture.String(),
+		"Received interrupt while stopping, exiting",
+		"",
+	)
+}
+
+func TestCommandCategories(t *testing.T) {
+
+	noop := NewCommand(func(ctx context.Context, cfg TestConfig) error {
+		return nil
+	})
+
+	root := NewCommandSet()
+	root.Add("version", noop, CommandWithDescription("Print the version"))
+	root.Add("serve", noop, CommandWithCategory("Server"), CommandWithDescription("Run the server"))
+	root.Add("migrate", noop, CommandWithCategory("Database"))
+	root.Add("healthcheck", noop, CommandWithCategory("Server"))
+
+	compareLines(t, root.Help(),
+		"Server:",
+		"  serve       - Run the server",
+		"  healthcheck - ",
+		"Database:",
+		"  migrate     - ",
+		"Other:",
+		"  version     - Print the version",
+	)
+}
+
+func TestHelpOutput(t *testing.T) {
+
+	ran := false
+	root := NewCommandSet()
+	root.Add("name", NewCommand(func(ctx context.Context, cfg TestConfig) error {
+		ran = true
+		return nil
+	}, WithDescription("Does the thing")))
+
+	t.Run("requested", func(t *testing.T) {
+		out := &bytes.Buffer{}
+		errOut := &bytes.Buffer{}
+		err := root.RunMainErr("test", "1.0", WithArgs([]string{"test", "name", "--foo=x", "--help"}), WithOutputWriter(out), WithErrorWriter(errOut))
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if ran {
+			t.Error("Expected the command not to run")
+		}
+		if errOut.Len() != 0 {
+			t.Errorf("Expected nothing on stderr, got %q", errOut.String())
+		}
+		compareLines(t, out.String(),
+			"Usage: test name [options]",
+			"Does the thing",
+			"Flags and Env Vars:",
+			"  --foo <string> / $FOO - foo description (required)",
+			"  --bar <string> / $BAR - bar description (default: bar)",
+			"",
+		)
+	})
+
+	t.Run("commands", func(t *testing.T) {
+		out := &bytes.Buffer{}
+		errOut := &bytes.Buffer{}
+		err := root.RunMainErr("test", "1.0", WithArgs([]string{"test", "-h"}), WithOutputWriter(out), WithErrorWriter(errOut))
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if errOut.Len() != 0 {
+			t.Errorf("Expected nothing on stderr, got %q", errOut.String())
+		}
+		compareLines(t, out.String(),
+			"Usage: test <command> [options]",
+			"  name - ",
+			"",
+		)
+	})
+
+	t.Run("error", func(t *testing.T) {
+		out := &bytes.Buffer{}
+		errOut := &bytes.Buffer{}
+		err := root.RunMainErr("test", "1.0", WithArgs([]string{"test", "name"}), WithOutputWriter(out), WithErrorWriter(errOut))
+		if err == nil {
+			t.Fatal("Expected an error")
+		}
+		if out.Len() != 0 {
+			t.Errorf("Expected nothing on stdout, got %q", out.String())
+		}
+		if !bytes.HasPrefix(errOut.Bytes(), []byte("Usage: test name [options]")) {
+			t.Errorf("Expected usage on stderr, got %q", errOut.String())
+		}
+	})
+
+	t.Run("after terminator", func(t *testing.T) {
+		type PassConfig struct {
+			Args []string `flag:",remaining"`
+		}
+		var got []string
+		cmd := NewCommand(func(ctx context.Context, cfg PassConfig) error {
+			got = cfg.Args
+			return nil
+		})
+		if err := cmd.Run(context.Background(), []string{"--", "--help"}); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if len(got) != 1 || got[0] != "--help" {
+			t.Errorf("Expected --help to be passed through, got %q", got)
+		}
+	})
+
+	t.Run("passthrough", func(t *testing.T) {
+		type ProxyConfig struct {
+			Verbose bool     `flag:"verbose"`
+			Name    string   `flag:"name" optional:"true"`
+			Target  string   `flag:",arg0"`
+			Args    []string `flag:",passthrough"`
+		}
+		for _, tc := range []struct {
+			args     []string
+			wantHelp bool
+			wantArgs []string
+		}{
+			{args: []string{"kubectl", "get", "--help"}, wantArgs: []string{"get", "--help"}},
+			{args: []string{"kubectl", "-h"}, wantArgs: []string{"-h"}},
+			{args: []string{"--verbose", "true", "kubectl", "--help"}, wantArgs: []string{"--help"}},
+			{args: []string{"--name", "x", "--help", "kubectl"}, wantHelp: true},
+			{args: []string{"--verbose", "-h", "kubectl"}, wantHelp: true},
+		} {
+			t.Run(strings.Join(tc.args, " "), func(t *testing.T) {
+				var got []string
+				cmd := NewCommand(func(ctx context.Context, cfg ProxyConfig) error {
+					got = cfg.Args
+					return nil
+				})
+				err := cmd.Run(context.Background(), tc.args)
+				if tc.wantHelp {
+					if !errors.Is(err, ErrHelpRequested) {
+						t.Fatalf("Expected help, got %v", err)
+					}
+					return
+				}
+				if err != nil {
+					t.Fatalf("Expected no error, got %v", err)
+				}
+				if !reflect.DeepEqual(got, tc.wantArgs) {
+					t.Errorf("Expected args %q, got %q", tc.wantArgs, got)
+				}
+			})
+		}
+	})
+}
+
+func TestVersion(t *testing.T) {
+
+	for _, tc := range []struct {
+		name        string
+		versionFunc func() string
+		args        []string
+		want        []string
+	}{{
+		name: "static",
+		args: []string{"test", "--version"},
+		want: []string{"test 1.0", ""},
+	}, {
+		name: "func",
+		versionFunc: func() string {
+			return "test 1.0\ncommit: abc123\n"
+		},
+		args: []string{"test", "version"},
+		want: []string{"test 1.0", "commit: abc123", ""},
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			root := NewCommandSet()
+			root.Add("serve", NewCommand(func(ctx context.Context, cfg TestConfig) error {
+				return nil
+			}))
+			if tc.versionFunc != nil {
+				root.WithVersionFunc(tc.versionFunc)
+			}
+
+			out := &bytes.Buffer{}
+			err := root.RunMainErr("test", "1.0", WithArgs(tc.args), WithOutputWriter(out))
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			compareLines(t, out.String(), tc.want...)
+		})
+	}
+
+	t.Run("own version command", func(t *testing.T) {
+		ran := false
+		root := NewCommandSet()
+		root.WithVersionFunc(func() string {
+			t.Error("Expected the version func not to be called")
+			return ""
+		})
+		root.Add("version", NewCommand(func(ctx context.Context, cfg struct{}) error {
+			ran = true
+			return nil
+		}))
+
+		err := root.RunMainErr("test", "1.0", WithArgs([]string{"test", "version"}), WithOutputWriter(&bytes.Buffer{}))
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if !ran {
+			t.Error("Expected the set's version command to run")
+		}
+	})
+}
@@ -0,0 +1,64 @@
+package commander
+
+import (
+	"io"
+	"os"
+	"strconv"
+
+	"github.com/mattn/go-isatty"
+)
+
+// terminal holds the environment checks which affect how output is rendered,
+// so that wrapping and color make the same decisions everywhere.
+type terminal struct {
+	width int
+	color bool
+}
+
+// detectTerminal inspects the environment for output written to out.
+//
+// Color is enabled only when out is a terminal, NO_COLOR is not set to a
+// non-empty value (https://no-color.org), and TERM is not "dumb".
+//
+// The width is taken from COLUMNS, then the size of the terminal on stdout or
+// stderr, falling back to defaultHelpWidth.
+func detectTerminal(out io.Writer) terminal {
+	return terminal{
+		width: terminalWidth(),
+		color: colorEnabled(out),
+	}
+}
+
+func terminalWidth() int {
+	if columns, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && columns > 0 {
+		return columns
+	}
+	// shells rarely export COLUMNS, so ask the terminal
+	for _, file := range []*os.File{os.Stdout, os.Stderr} {
+		if columns := ttyWidth(file.Fd()); columns > 0 {
+			return columns
+		}
+	}
+	return defaultHelpWidth
+}
+
+func colorEnabled(out io.Writer) bool {
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	if os.Getenv("TERM") == "dumb" {
+		return false
+	}
+	file, ok := out.(*os.File)
+	if !ok {
+		return false
+	}
+	return isatty.IsTerminal(file.Fd()) || isatty.IsCygwinTerminal(file.Fd())
+}
+
+func (t terminal) bold(text string) string {
+	if !t.color {
+		return text
+	}
+	return "\033[1m" + text + "\033[0m"
+}
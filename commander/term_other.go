@@ -0,0 +1,9 @@
+//go:build !linux && !darwin && !dragonfly && !freebsd && !netbsd && !openbsd
+
+package commander
+
+// ttyWidth is not supported on this platform, the width comes from COLUMNS or
+// defaults.
+func ttyWidth(fd uintptr) int {
+	return 0
+}
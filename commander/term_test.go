@@ -0,0 +1,60 @@
+package commander
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestNoColor(t *testing.T) {
+	t.Setenv("NO_COLOR", "1")
+
+	if colorEnabled(os.Stdout) {
+		t.Errorf("Expected color to be disabled by NO_COLOR")
+	}
+
+	root := NewCommandSet()
+	root.Add("name", NewCommand(func(ctx context.Context, cfg TestConfig) error {
+		return nil
+	}))
+
+	capture := &bytes.Buffer{}
+	root.runMain(context.Background(), capture, capture, []string{"test", "name"})
+	if strings.Contains(capture.String(), "\033") {
+		t.Errorf("Expected no escape codes, got %q", capture.String())
+	}
+}
+
+func TestColumns(t *testing.T) {
+	t.Setenv("COLUMNS", "40")
+
+	type LongConfig struct {
+		Mode string `flag:"mode" description:"the mode to run in, which controls routing"`
+	}
+
+	cc := NewCommand(func(ctx context.Context, cfg LongConfig) error {
+		return nil
+	})
+
+	compareLines(t, cc.Help(),
+		"",
+		"  --mode <string> - the mode to run in,",
+		"                    which controls",
+		"                    routing (required)",
+	)
+}
+
+func TestTTYWidthNotTerminal(t *testing.T) {
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reader.Close()
+	defer writer.Close()
+
+	if width := ttyWidth(writer.Fd()); width != 0 {
+		t.Errorf("Expected no width for a pipe, got %d", width)
+	}
+}
@@ -0,0 +1,15 @@
+//go:build linux || darwin || dragonfly || freebsd || netbsd || openbsd
+
+package commander
+
+import "golang.org/x/sys/unix"
+
+// ttyWidth returns the number of columns of the terminal open on fd, or 0 when
+// fd is not a terminal.
+func ttyWidth(fd uintptr) int {
+	winsize, err := unix.IoctlGetWinsize(int(fd), unix.TIOCGWINSZ)
+	if err != nil {
+		return 0
+	}
+	return int(winsize.Col)
+}
@@ -0,0 +1,99 @@
+package runner
+
+import "time"
+
+// EventType identifies a RunnerEvent
+type EventType int
+
+const (
+	// EventGroupStarted is sent by Start, before any runner event
+	EventGroupStarted EventType = iota
+
+	// EventRunnerStarted is sent when a runner starts, after any
+	// dependencies are ready
+	EventRunnerStarted
+
+	// EventRunnerExited is sent when a runner exits, or fails to start, with
+	// the error it returned, which may be nil, context.Canceled or
+	// ErrStopGroup
+	EventRunnerExited
+
+	// EventGroupExited is sent when Wait returns, with the error it returns.
+	// It is the last event, and the channel is closed after it.
+	EventGroupExited
+)
+
+func (et EventType) String() string {
+	switch et {
+	case EventGroupStarted:
+		return "GroupStarted"
+	case EventRunnerStarted:
+		return "RunnerStarted"
+	case EventRunnerExited:
+		return "RunnerExited"
+	case EventGroupExited:
+		return "GroupExited"
+	}
+	return "Unknown"
+}
+
+// RunnerEvent describes a change in the lifecycle of a group or its runners,
+// see Group.Events
+type RunnerEvent struct {
+	Type EventType
+
+	// Runner is the name of the runner, empty for group events
+	Runner string
+
+	// Err is set for exit events
+	Err error
+
+	Time time.Time
+}
+
+// EventBufferSize is the capacity of the channel returned by Events
+const EventBufferSize = 100
+
+// Events returns a channel of lifecycle events, for building dashboards and
+// the like without parsing logs. Call it before Start to see every event.
+//
+// Sending never blocks the runners: the channel is buffered with
+// EventBufferSize, and events are dropped while the buffer is full, so the
+// channel should be drained promptly. The channel is closed once Wait
+// returns, after EventGroupExited.
+func (gg *Group) Events() <-chan RunnerEvent {
+	gg.eventsMutex.Lock()
+	defer gg.eventsMutex.Unlock()
+	if gg.events == nil {
+		gg.events = make(chan RunnerEvent, EventBufferSize)
+		if gg.eventsClosed {
+			close(gg.events)
+		}
+	}
+	return gg.events
+}
+
+func (gg *Group) emit(event RunnerEvent) {
+	gg.eventsMutex.Lock()
+	defer gg.eventsMutex.Unlock()
+	if gg.events == nil || gg.eventsClosed {
+		return
+	}
+	event.Time = time.Now()
+	select {
+	case gg.events <- event:
+	default:
+	}
+}
+
+func (gg *Group) closeEvents() {
+	gg.eventsMutex.Lock()
+	defer gg.eventsMutex.Unlock()
+	if gg.eventsClosed {
+		return
+	}
+	gg.eventsClosed = true
+	if gg.events != nil {
+		close(gg.events)
+	}
+}
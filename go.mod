@@ -5,17 +5,17 @@ go 1.22.0
 toolchain go1.22.4
 
 require (
+	github.com/mattn/go-isatty v0.0.20
 	github.com/pentops/log.go v0.0.0-20240930194039-e8e09c525e33
 	github.com/stretchr/testify v1.9.0
 	golang.org/x/sync v0.8.0
+	golang.org/x/sys v0.25.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
 	github.com/davecgh/go-spew v1.1.1 // indirect
 	github.com/fatih/color v1.17.0 // indirect
 	github.com/mattn/go-colorable v0.1.13 // indirect
-	github.com/mattn/go-isatty v0.0.20 // indirect
 	github.com/pmezard/go-difflib v1.0.0 // indirect
-	golang.org/x/sys v0.25.0 // indirect
-	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
@@ -1,6 +1,6 @@
 // Package parallel provides a simpler version of errgroup where:
-// - all goroutines are started immediately
-// - no concurrency limit
+// - all goroutines are started immediately, unless a limit is set
+// - no concurrency limit by default
 // - runners receive a context
 // - the context is canceled without passing 'cause'.
 //
@@ -12,6 +12,8 @@ package parallel
 
 import (
 	"context"
+	"fmt"
+	"runtime/debug"
 	"sync"
 )
 
@@ -21,9 +23,11 @@ type Group struct {
 	ctx    context.Context
 	cancel context.CancelFunc
 	wg     sync.WaitGroup
+	sem    chan struct{}
 
 	lock     sync.Mutex
 	firstErr error
+	allErrs  []error
 }
 
 func NewGroup(ctx context.Context) *Group {
@@ -31,7 +35,26 @@ func NewGroup(ctx context.Context) *Group {
 	return &Group{ctx: innerCtx, cancel: cancel}
 }
 
-// Go calls the given function in a new goroutine immediately.
+// SetLimit limits the number of active goroutines in this group to at most n.
+// A negative value indicates no limit.
+//
+// Any subsequent call to Go will block until it can add an active goroutine
+// without exceeding the configured limit.
+//
+// The limit must not be modified while any goroutines in the group are active.
+func (g *Group) SetLimit(n int) {
+	if n < 0 {
+		g.sem = nil
+		return
+	}
+	if len(g.sem) != 0 {
+		panic(fmt.Errorf("parallel: modify limit while %v goroutines in the group are still active", len(g.sem)))
+	}
+	g.sem = make(chan struct{}, n)
+}
+
+// Go calls the given function in a new goroutine immediately, or once a slot
+// is available if a limit is set.
 //
 // The derived Context is canceled the first time a function passed to Go
 // returns a non-nil error or the first time Wait returns, whichever occurs
@@ -40,26 +63,108 @@ func NewGroup(ctx context.Context) *Group {
 // The context is canceled with a simpel context.WithCancel, not a
 // context.WithCancelCause.
 //
-// The error will be returned by Wait.
+// The error will be returned by Wait. A panic in the function is recovered,
+// and handled as a PanicError.
 func (g *Group) Go(f func(ctx context.Context) error) {
+	if g.sem != nil {
+		g.sem <- struct{}{}
+	}
+	g.run(f)
+}
+
+// TryGo calls the given function in a new goroutine only if the number of
+// active goroutines in the group is currently below the configured limit.
+//
+// The return value reports whether the goroutine was started.
+func (g *Group) TryGo(f func(ctx context.Context) error) bool {
+	if g.sem != nil {
+		select {
+		case g.sem <- struct{}{}:
+		default:
+			return false
+		}
+	}
+	g.run(f)
+	return true
+}
+
+func (g *Group) run(f func(ctx context.Context) error) {
 	g.wg.Add(1)
 	go func() {
-		defer g.wg.Done()
-		if err := f(g.ctx); err != nil {
+		defer g.done()
+		if err := g.call(f); err != nil {
 			g.handleErr(err)
 		}
 	}()
 }
 
+// PanicError is the error for a function passed to Go which panicked. It is
+// handled in the same way as a returned error.
+type PanicError struct {
+	Value interface{}
+	Stack []byte
+}
+
+func (pe PanicError) Error() string {
+	return fmt.Sprintf("panic: %v\n%s", pe.Value, pe.Stack)
+}
+
+// call runs f with the group's context, converting a panic to a PanicError
+func (g *Group) call(f func(ctx context.Context) error) error {
+	return callRecover(g.ctx, f)
+}
+
+func callRecover(ctx context.Context, f func(ctx context.Context) error) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = PanicError{
+				Value: r,
+				Stack: debug.Stack(),
+			}
+		}
+	}()
+	return f(ctx)
+}
+
+func (g *Group) done() {
+	if g.sem != nil {
+		<-g.sem
+	}
+	g.wg.Done()
+}
+
+// GoNamed calls the given function in the same way as Go, but wraps any
+// returned error, or PanicError, with the name, so that Wait reports which
+// task failed.
+func (g *Group) GoNamed(name string, f func(ctx context.Context) error) {
+	g.Go(func(ctx context.Context) error {
+		if err := callRecover(ctx, f); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		return nil
+	})
+}
+
 func (g *Group) handleErr(err error) {
 	g.lock.Lock()
 	if g.firstErr == nil {
 		g.firstErr = err
 	}
+	g.allErrs = append(g.allErrs, err)
 	g.lock.Unlock()
 	g.cancel()
 }
 
+// Err returns the first error returned by a function passed to Go, or nil if
+// there hasn't been one yet. It does not block, so a function can check why
+// its context was canceled without waiting for Wait. Unlike Wait, it does not
+// return the parent context's error.
+func (g *Group) Err() error {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	return g.firstErr
+}
+
 func (g *Group) Wait() error {
 	g.wg.Wait()
 	if g.firstErr != nil {
@@ -70,3 +175,44 @@ func (g *Group) Wait() error {
 	// so we return the context error.
 	return g.ctx.Err()
 }
+
+// AllErrors returns every non-nil error returned by the functions passed to Go.
+// The first error is still the one which cancels the context and is returned
+// by Wait. The order of the errors is the order in which the functions
+// returned, which is not deterministic.
+//
+// AllErrors should be called after Wait has returned.
+func (g *Group) AllErrors() []error {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	errs := make([]error, len(g.allErrs))
+	copy(errs, g.allErrs)
+	return errs
+}
+
+// Map calls fn for each item in a separate goroutine, returning the results in
+// the same order as the input items.
+// If any call returns an error, the context passed to the other calls is
+// canceled and the first error is returned.
+func Map[T, R any](ctx context.Context, items []T, fn func(context.Context, T) (R, error)) ([]R, error) {
+	group := NewGroup(ctx)
+	// releases the group's context once every call has returned
+	defer group.cancel()
+	results := make([]R, len(items))
+	for idx, item := range items {
+		idx, item := idx, item
+		group.Go(func(ctx context.Context) error {
+			result, err := fn(ctx, item)
+			if err != nil {
+				return err
+			}
+			results[idx] = result
+			return nil
+		})
+	}
+
+	if err := group.Wait(); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
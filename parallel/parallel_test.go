@@ -2,8 +2,11 @@ package parallel
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestParallel(t *testing.T) {
@@ -111,3 +114,249 @@ func TestCancel(t *testing.T) {
 	}
 
 }
+
+func TestAllErrors(t *testing.T) {
+	ctx := context.Background()
+	group := NewGroup(ctx)
+
+	testErrs := []error{
+		fmt.Errorf("err 1"),
+		fmt.Errorf("err 2"),
+		fmt.Errorf("err 3"),
+	}
+
+	for _, testErr := range testErrs {
+		testErr := testErr
+		group.Go(func(ctx context.Context) error {
+			return testErr
+		})
+	}
+	group.Go(func(ctx context.Context) error {
+		return nil
+	})
+
+	err := group.Wait()
+	if err == nil {
+		t.Errorf("expected error")
+	}
+
+	allErrs := group.AllErrors()
+	if len(allErrs) != len(testErrs) {
+		t.Fatalf("expected %d errors, got %d", len(testErrs), len(allErrs))
+	}
+
+	for _, testErr := range testErrs {
+		found := false
+		for _, gotErr := range allErrs {
+			if gotErr == testErr {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("missing error %v", testErr)
+		}
+	}
+
+	if allErrs[0] != err {
+		t.Errorf("expected the first error to be returned by Wait, got %v", err)
+	}
+}
+
+func TestMap(t *testing.T) {
+	ctx := context.Background()
+
+	items := []int{5, 4, 3, 2, 1}
+	results, err := Map(ctx, items, func(ctx context.Context, item int) (string, error) {
+		// later items finish first
+		time.Sleep(time.Duration(item) * time.Millisecond)
+		return fmt.Sprintf("item %d", item), nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(results) != len(items) {
+		t.Fatalf("expected %d results, got %d", len(items), len(results))
+	}
+	for idx, item := range items {
+		want := fmt.Sprintf("item %d", item)
+		if results[idx] != want {
+			t.Errorf("result %d: expected %q, got %q", idx, want, results[idx])
+		}
+	}
+}
+
+func TestMapCancelsContext(t *testing.T) {
+	ctx := context.Background()
+
+	var fnCtx context.Context
+	_, err := Map(ctx, []int{1}, func(ctx context.Context, item int) (int, error) {
+		fnCtx = ctx
+		return item, nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if fnCtx.Err() == nil {
+		t.Errorf("expected the context to be canceled once Map returns")
+	}
+}
+
+func TestMapErr(t *testing.T) {
+	ctx := context.Background()
+
+	testErr := fmt.Errorf("test err")
+	_, err := Map(ctx, []int{1, 2}, func(ctx context.Context, item int) (int, error) {
+		if item == 1 {
+			return 0, testErr
+		}
+		<-ctx.Done()
+		return 0, nil
+	})
+	if err != testErr {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestGoNamed(t *testing.T) {
+	ctx := context.Background()
+	group := NewGroup(ctx)
+
+	testErr := fmt.Errorf("test err")
+
+	group.GoNamed("task1", func(ctx context.Context) error {
+		return testErr
+	})
+
+	err := group.Wait()
+	if !errors.Is(err, testErr) {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	if err.Error() != "task1: test err" {
+		t.Errorf("unexpected error message: %v", err)
+	}
+}
+
+func TestGoNamedPanic(t *testing.T) {
+	group := NewGroup(context.Background())
+
+	group.GoNamed("task1", func(ctx context.Context) error {
+		panic("boom")
+	})
+
+	err := group.Wait()
+	panicErr := PanicError{}
+	if !errors.As(err, &panicErr) {
+		t.Fatalf("expected PanicError, got %v", err)
+	}
+	if panicErr.Value != "boom" {
+		t.Errorf("unexpected panic value: %v", panicErr.Value)
+	}
+	if !strings.HasPrefix(err.Error(), "task1: panic: boom") {
+		t.Errorf("expected the panic to be named, got %q", err.Error())
+	}
+}
+
+func TestTryGo(t *testing.T) {
+	ctx := context.Background()
+	group := NewGroup(ctx)
+	group.SetLimit(2)
+
+	hold := make(chan struct{})
+	started := make(chan struct{})
+
+	for i := 0; i < 2; i++ {
+		if !group.TryGo(func(ctx context.Context) error {
+			started <- struct{}{}
+			<-hold
+			return nil
+		}) {
+			t.Fatalf("TryGo %d should have started", i)
+		}
+	}
+	<-started
+	<-started
+
+	if group.TryGo(func(ctx context.Context) error {
+		t.Error("should not run over the limit")
+		return nil
+	}) {
+		t.Errorf("TryGo should return false when the limit is reached")
+	}
+
+	close(hold)
+
+	err := group.Wait()
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	if !group.TryGo(func(ctx context.Context) error {
+		return nil
+	}) {
+		t.Errorf("TryGo should start once slots are released")
+	}
+
+	err = group.Wait()
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestPanic(t *testing.T) {
+	group := NewGroup(context.Background())
+
+	siblingStopped := false
+	group.Go(func(ctx context.Context) error {
+		<-ctx.Done()
+		siblingStopped = true
+		return nil
+	})
+
+	group.Go(func(ctx context.Context) error {
+		panic("boom")
+	})
+
+	err := group.Wait()
+	panicErr := PanicError{}
+	if !errors.As(err, &panicErr) {
+		t.Fatalf("expected PanicError, got %v", err)
+	}
+	if panicErr.Value != "boom" {
+		t.Errorf("expected panic value 'boom', got %v", panicErr.Value)
+	}
+	if !strings.Contains(string(panicErr.Stack), "TestPanic") {
+		t.Errorf("expected stack to include the panicking function, got %s", panicErr.Stack)
+	}
+	if !siblingStopped {
+		t.Errorf("sibling should have been canceled")
+	}
+}
+
+func TestErrWhileRunning(t *testing.T) {
+	group := NewGroup(context.Background())
+
+	if err := group.Err(); err != nil {
+		t.Errorf("expected no error before any task fails, got %v", err)
+	}
+
+	failure := errors.New("failure")
+	var seenErr error
+	group.Go(func(ctx context.Context) error {
+		<-ctx.Done()
+		seenErr = group.Err()
+		return nil
+	})
+
+	group.Go(func(ctx context.Context) error {
+		return failure
+	})
+
+	if err := group.Wait(); !errors.Is(err, failure) {
+		t.Errorf("expected failure from Wait, got %v", err)
+	}
+	if !errors.Is(seenErr, failure) {
+		t.Errorf("expected the canceled task to see the failure, got %v", seenErr)
+	}
+}
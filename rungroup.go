@@ -4,10 +4,14 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"log/slog"
 	"os"
 	"os/signal"
+	"slices"
+	"strings"
 	"sync"
 	"syscall"
+	"time"
 
 	"github.com/pentops/log.go/log"
 	"golang.org/x/sync/errgroup"
@@ -21,12 +25,45 @@ const (
 	LogLineRunnerExited                         = "Runner exited"
 	LogLineRunnerExitedWithError                = "Runner exited with error"
 	LogLineRunnerExitedWithContextCanceledError = "Runner exited with context canceled"
+	LogLineRunnerWaitingForDependencies         = "Runner waiting for dependencies"
+	LogLineRunnerStoppedGroup                   = "Runner stopped the group"
+	LogLineRunnerErrorInGracePeriod             = "Runner exited with error during grace period"
+	LogLineGroupReceivedSignal                  = "Run group received signal, stopping"
+	LogLineGroupForceExit                       = "Run group received second signal, exiting"
+	LogLineGroupReloading                       = "Run group received reload signal"
+	LogLineGroupReloadFailed                    = "Run group reload failed"
 )
 
+// DefaultForceExitCode is the exit code used when a second signal is received
+// while the group is stopping, matching the shell convention for SIGINT.
+const DefaultForceExitCode = 130
+
+// ErrStopGroup can be returned by a runner to shut the group down gracefully,
+// e.g. when the main server exits cleanly and its sidecars should stop. The
+// context passed to the other runners is canceled, and Wait returns nil unless
+// another runner failed first.
+var ErrStopGroup = errors.New("stop group")
+
+// ErrGroupDeadline is returned by Wait when the group's WithDeadline elapses.
+// It also wraps context.DeadlineExceeded.
+var ErrGroupDeadline = errors.New("group deadline exceeded")
+
 type Group struct {
 	name            string
 	logger          log.Logger
 	cancelOnSignals []os.Signal
+	forceExitCode   int
+	logMessages     map[string]string
+	logLevels       map[string]slog.Level
+	reloadSignal    os.Signal
+	reload          func(context.Context) error
+	deadline        time.Duration
+
+	// signals, reloadSignals and exit are replaced in tests
+	signals       chan os.Signal
+	reloadSignals chan os.Signal
+	exit          func(int)
+	stopSignals   []func()
 
 	running   bool
 	isWaiting bool
@@ -37,22 +74,156 @@ type Group struct {
 	runContext   context.Context
 
 	holdOpen chan struct{}
+
+	// deadlineContext is set by Start when the group has a deadline
+	deadlineContext context.Context
+	cancelDeadline  context.CancelFunc
+
+	done     chan struct{}
+	doneOnce sync.Once
+
+	// stages hold the context for each shutdown priority, stopping is set
+	// once they are being canceled
+	stages   []*shutdownStage
+	stopping bool
+
+	events       chan RunnerEvent
+	eventsClosed bool
+	eventsMutex  sync.Mutex
 }
 
 type runner struct {
-	name    string
-	f       func(ctx context.Context) error
-	stopped chan struct{}
+	name      string
+	f         func(ctx context.Context, ready func()) error
+	dependsOn []string
+	timeout   time.Duration
+	grace     time.Duration
+	fields    map[string]interface{}
+	priority  int
+	stopped   chan struct{}
+
+	ready     chan struct{}
+	readyOnce sync.Once
+}
+
+func (rr *runner) markReady() {
+	rr.readyOnce.Do(func() {
+		close(rr.ready)
+	})
 }
 
 type option func(*Group)
 
+type runnerOption func(*runner)
+
+// DependsOn defers starting the runner until each of the named runners has
+// signaled readiness. Runners added with Add are ready as soon as they start,
+// runners added with AddReady are ready when they call the ready callback.
+func DependsOn(names ...string) runnerOption {
+	return func(rr *runner) {
+		rr.dependsOn = append(rr.dependsOn, names...)
+	}
+}
+
+// WithTimeout cancels the runner's context once it has run for the duration.
+// When the runner returns the resulting context error, it fails the group like
+// any other error. The timeout starts once any dependencies are ready.
+func WithTimeout(timeout time.Duration) runnerOption {
+	return func(rr *runner) {
+		rr.timeout = timeout
+	}
+}
+
+// WithGracePeriod ignores errors from a runner which has run for less than the
+// grace period, e.g. a flaky dependency failing during startup. The error is
+// logged as a warning, and the runner exits without stopping the group, as if
+// it had returned nil. Errors after the grace period fail the group as normal.
+// Runners which never start, because a dependency failed, are not covered.
+func WithGracePeriod(grace time.Duration) runnerOption {
+	return func(rr *runner) {
+		rr.grace = grace
+	}
+}
+
+// WithShutdownPriority orders how the group stops, e.g. to stop accepting
+// traffic before closing the database the server uses. Runners are stopped in
+// stages from the highest priority to the lowest: once the group's context is
+// done, the context of the runners with the highest priority is canceled, and
+// when they have all exited the next stage is canceled, and so on. Runners
+// with the same priority stop together. The default priority is 0, so
+// negative priorities stop after the default, positive before it.
+// A deadline, from the parent context or WithDeadline, still applies to every
+// stage at once.
+func WithShutdownPriority(priority int) runnerOption {
+	return func(rr *runner) {
+		rr.priority = priority
+	}
+}
+
+// WithFields adds fields to the context passed to the runner, so they appear
+// on the group's logs for the runner, and on anything the runner logs.
+func WithFields(fields map[string]interface{}) runnerOption {
+	return func(rr *runner) {
+		if rr.fields == nil {
+			rr.fields = map[string]interface{}{}
+		}
+		for key, val := range fields {
+			rr.fields[key] = val
+		}
+	}
+}
+
 func WithLogger(logger log.Logger) option {
 	return func(g *Group) {
 		g.logger = logger
 	}
 }
 
+// WithLogMessage replaces the message logged for an event. Events are
+// identified by their default message, one of the LogLine constants, e.g.
+// WithLogMessage(LogLineGroupStarted, "Server starting").
+func WithLogMessage(event string, message string) option {
+	return func(g *Group) {
+		if g.logMessages == nil {
+			g.logMessages = map[string]string{}
+		}
+		g.logMessages[event] = message
+	}
+}
+
+// WithLogLevel overrides the level an event is logged at, identified in the
+// same way as WithLogMessage, e.g. WithLogLevel(LogLineGroupExited,
+// slog.LevelDebug).
+func WithLogLevel(event string, level slog.Level) option {
+	return func(g *Group) {
+		if g.logLevels == nil {
+			g.logLevels = map[string]slog.Level{}
+		}
+		g.logLevels[event] = level
+	}
+}
+
+func (gg *Group) log(ctx context.Context, level slog.Level, event string) {
+	message := event
+	if override, ok := gg.logMessages[event]; ok {
+		message = override
+	}
+	if override, ok := gg.logLevels[event]; ok {
+		level = override
+	}
+
+	switch {
+	case level >= slog.LevelError:
+		gg.logger.Error(ctx, message)
+	case level >= slog.LevelWarn:
+		gg.logger.Warn(ctx, message)
+	case level >= slog.LevelInfo:
+		gg.logger.Info(ctx, message)
+	default:
+		gg.logger.Debug(ctx, message)
+	}
+}
+
 func WithName(name string) option {
 	return func(g *Group) {
 		g.name = name
@@ -62,6 +233,8 @@ func WithName(name string) option {
 // WithCancelOnSignals will cancel the context when any of the given signals
 // are received. If no signals are given, the default signals are used:
 // os.Interrupt, os.Kill, syscall.SIGTERM
+// If a second signal is received before the group exits, the process exits
+// immediately with the force exit code, so a hung shutdown can be escaped.
 func WithCancelOnSignals(signals ...os.Signal) option {
 	if len(signals) == 0 {
 		signals = []os.Signal{
@@ -75,9 +248,44 @@ func WithCancelOnSignals(signals ...os.Signal) option {
 	}
 }
 
+// WithReloadSignal calls reload each time the signal, e.g. syscall.SIGHUP, is
+// received while the group is running, rather than stopping the group, e.g.
+// to rotate certificates or re-read config. Reloads run one at a time, with
+// the group's context, and the runners keep running throughout. An error from
+// reload is logged, and does not stop the group. The signal should not also
+// be passed to WithCancelOnSignals.
+func WithReloadSignal(sig os.Signal, reload func(ctx context.Context) error) option {
+	return func(g *Group) {
+		g.reloadSignal = sig
+		g.reload = reload
+	}
+}
+
+// WithDeadline limits the total time the group runs for, from Start, e.g. for
+// batch jobs which must not overrun. When it elapses, the context passed to
+// every runner is canceled, and Wait returns an error wrapping
+// ErrGroupDeadline, unless a runner had already failed. A deadline on the
+// context passed to Start which fires first is not reported as
+// ErrGroupDeadline.
+func WithDeadline(deadline time.Duration) option {
+	return func(g *Group) {
+		g.deadline = deadline
+	}
+}
+
+// WithForceExitCode sets the exit code used when a second signal is received,
+// see WithCancelOnSignals. Defaults to DefaultForceExitCode.
+func WithForceExitCode(code int) option {
+	return func(g *Group) {
+		g.forceExitCode = code
+	}
+}
+
 func NewGroup(options ...option) *Group {
 	gg := &Group{
-		logger: log.DefaultLogger,
+		logger:        log.DefaultLogger,
+		forceExitCode: DefaultForceExitCode,
+		exit:          os.Exit,
 	}
 	for _, option := range options {
 		option(gg)
@@ -88,7 +296,18 @@ func NewGroup(options ...option) *Group {
 // Add registers a function to run when the group is triggered with Run or Start.
 // If the group is already running, the function will be started immediately and
 // added to the pool.
-func (gg *Group) Add(name string, f func(ctx context.Context) error) {
+// The runner is considered ready as soon as it starts.
+func (gg *Group) Add(name string, f func(ctx context.Context) error, options ...runnerOption) {
+	gg.AddReady(name, func(ctx context.Context, ready func()) error {
+		ready()
+		return f(ctx)
+	}, options...)
+}
+
+// AddReady registers a function in the same way as Add, but the runner is only
+// considered ready once it calls the ready callback. Runners which depend on
+// this runner will not be started until then.
+func (gg *Group) AddReady(name string, f func(ctx context.Context, ready func()) error, options ...runnerOption) {
 	gg.controlMutex.Lock()
 	defer gg.controlMutex.Unlock()
 
@@ -96,7 +315,15 @@ func (gg *Group) Add(name string, f func(ctx context.Context) error) {
 		panic("group is already waiting")
 	}
 
-	runner := &runner{name: name, f: f}
+	runner := &runner{
+		name:    name,
+		f:       f,
+		stopped: make(chan struct{}),
+		ready:   make(chan struct{}),
+	}
+	for _, option := range options {
+		option(runner)
+	}
 	gg.runners = append(gg.runners, runner)
 	if gg.running {
 		gg.startRunner(gg.runContext, runner)
@@ -104,38 +331,294 @@ func (gg *Group) Add(name string, f func(ctx context.Context) error) {
 
 }
 
+func (gg *Group) findRunner(name string) (*runner, bool) {
+	for _, rr := range gg.runners {
+		if rr.name == name {
+			return rr, true
+		}
+	}
+	return nil, false
+}
+
+// checkDependencies ensures every dependency refers to a registered runner, and
+// that there are no cycles. A cycle would otherwise deadlock: each runner in
+// the cycle waits for the next to be ready, none of them start, and the group
+// stays open until the context is canceled. Cycles are found with a depth
+// first walk of the dependency graph, a runner seen again while it is still on
+// the current path closes a cycle.
+func (gg *Group) checkDependencies() error {
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := map[string]int{}
+
+	var visit func(rr *runner, path []string) error
+	visit = func(rr *runner, path []string) error {
+		path = append(path, rr.name)
+		switch state[rr.name] {
+		case visited:
+			return nil
+		case visiting:
+			return fmt.Errorf("runner dependency cycle: %s", strings.Join(path, " -> "))
+		}
+		state[rr.name] = visiting
+		for _, depName := range rr.dependsOn {
+			dep, ok := gg.findRunner(depName)
+			if !ok {
+				return fmt.Errorf("runner %q depends on unknown runner %q", rr.name, depName)
+			}
+			if err := visit(dep, path); err != nil {
+				return err
+			}
+		}
+		state[rr.name] = visited
+		return nil
+	}
+
+	for _, rr := range gg.runners {
+		if err := visit(rr, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func waitForReady(ctx context.Context, runners []*runner) error {
+	for _, rr := range runners {
+		select {
+		case <-rr.ready:
+		case <-rr.stopped:
+			// The runner may have signaled ready and then exited
+			select {
+			case <-rr.ready:
+			default:
+				return fmt.Errorf("runner %q exited before it was ready", rr.name)
+			}
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+func (gg *Group) runRunner(ctx context.Context, rr *runner) error {
+	if rr.timeout <= 0 {
+		return rr.f(ctx, rr.markReady)
+	}
+
+	runCtx, cancel := context.WithTimeout(ctx, rr.timeout)
+	defer cancel()
+	err := rr.f(runCtx, rr.markReady)
+	if err != nil && errors.Is(runCtx.Err(), context.DeadlineExceeded) && ctx.Err() == nil {
+		return fmt.Errorf("runner %q timed out after %s: %w", rr.name, rr.timeout, err)
+	}
+	return err
+}
+
+// shutdownStage is the context shared by the runners with a shutdown priority
+type shutdownStage struct {
+	priority int
+	ctx      context.Context
+	cancel   context.CancelFunc
+	runners  []*runner
+}
+
+// stageContext returns the context for the runner's shutdown stage, which
+// keeps the values and deadline of the group's context, but is only canceled
+// by stopStages. Must be called with the control mutex held.
+func (gg *Group) stageContext(ctx context.Context, rr *runner) context.Context {
+	if gg.stopping {
+		// Already stopping, so the group's context is done
+		return ctx
+	}
+	for _, stage := range gg.stages {
+		if stage.priority == rr.priority {
+			stage.runners = append(stage.runners, rr)
+			return stage.ctx
+		}
+	}
+
+	stage := &shutdownStage{
+		priority: rr.priority,
+		runners:  []*runner{rr},
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		stage.ctx, stage.cancel = context.WithDeadline(context.WithoutCancel(ctx), deadline)
+	} else {
+		stage.ctx, stage.cancel = context.WithCancel(context.WithoutCancel(ctx))
+	}
+	gg.stages = append(gg.stages, stage)
+	return stage.ctx
+}
+
+// stopStages waits for the group's context to be done, then cancels each
+// shutdown stage in turn, from the highest priority, waiting for its runners
+// to exit before moving on to the next.
+func (gg *Group) stopStages(ctx context.Context) {
+	<-ctx.Done()
+
+	gg.controlMutex.Lock()
+	gg.stopping = true
+	stages := make([]shutdownStage, len(gg.stages))
+	for idx, stage := range gg.stages {
+		stages[idx] = *stage
+		stages[idx].runners = slices.Clone(stage.runners)
+	}
+	gg.controlMutex.Unlock()
+
+	slices.SortFunc(stages, func(a, b shutdownStage) int {
+		return b.priority - a.priority
+	})
+	for _, stage := range stages {
+		stage.cancel()
+		for _, rr := range stage.runners {
+			<-rr.stopped
+		}
+	}
+}
+
 func (gg *Group) startRunner(ctx context.Context, rr *runner) {
-	rr.stopped = make(chan struct{})
+	ctx = gg.stageContext(ctx, rr)
+	if len(rr.fields) > 0 {
+		ctx = log.WithFields(ctx, rr.fields)
+	}
 	ctx = log.WithField(ctx, "runner", rr.name)
+
+	deps := make([]*runner, 0, len(rr.dependsOn))
+	var depErr error
+	for _, depName := range rr.dependsOn {
+		dep, ok := gg.findRunner(depName)
+		if !ok {
+			depErr = fmt.Errorf("runner %q depends on unknown runner %q", rr.name, depName)
+			break
+		}
+		deps = append(deps, dep)
+	}
+
 	gg.errGroup.Go(func() error {
-		gg.logger.Info(ctx, LogLineRunnerStarted)
-		err := rr.f(ctx)
+		err := depErr
+		if err == nil && len(deps) > 0 {
+			gg.log(ctx, slog.LevelDebug, LogLineRunnerWaitingForDependencies)
+			err = waitForReady(ctx, deps)
+		}
+		var ranFor time.Duration
+		ran := false
+		if err == nil {
+			gg.log(ctx, slog.LevelInfo, LogLineRunnerStarted)
+			gg.emit(RunnerEvent{Type: EventRunnerStarted, Runner: rr.name})
+			started := time.Now()
+			err = gg.runRunner(ctx, rr)
+			ranFor = time.Since(started)
+			ran = true
+		}
 		close(rr.stopped)
+		gg.emit(RunnerEvent{Type: EventRunnerExited, Runner: rr.name, Err: err})
+		if errors.Is(err, ErrStopGroup) {
+			// Returned to the errgroup to cancel the context, Wait treats
+			// it as a clean exit
+			gg.log(ctx, slog.LevelInfo, LogLineRunnerStoppedGroup)
+			return err
+		}
 		if err == nil {
-			gg.logger.Info(ctx, LogLineRunnerExited)
+			gg.log(ctx, slog.LevelInfo, LogLineRunnerExited)
 			return nil
 		}
 		if errors.Is(err, context.Canceled) {
-			gg.logger.Debug(ctx, LogLineRunnerExitedWithContextCanceledError)
+			gg.log(ctx, slog.LevelDebug, LogLineRunnerExitedWithContextCanceledError)
 			return nil
 		}
-		gg.logger.Error(log.WithError(ctx, err), LogLineRunnerExitedWithError)
+		if ran && ranFor < rr.grace {
+			gg.log(log.WithError(ctx, err), slog.LevelWarn, LogLineRunnerErrorInGracePeriod)
+			return nil
+		}
+		gg.log(log.WithError(ctx, err), slog.LevelError, LogLineRunnerExitedWithError)
 		return err
 	})
 }
 
+// watchSignals returns a context which is canceled on the first signal. A
+// second signal exits the process. Watching stops when Wait returns.
+func (gg *Group) watchSignals(ctx context.Context) context.Context {
+	ctx, cancel := context.WithCancel(ctx)
+
+	signals := gg.signals
+	if signals == nil {
+		signals = make(chan os.Signal, 2)
+		signal.Notify(signals, gg.cancelOnSignals...)
+	}
+
+	done := make(chan struct{})
+	gg.stopSignals = append(gg.stopSignals, func() {
+		signal.Stop(signals)
+		close(done)
+	})
+
+	go func() {
+		select {
+		case sig := <-signals:
+			gg.log(log.WithField(ctx, "signal", sig.String()), slog.LevelInfo, LogLineGroupReceivedSignal)
+			cancel()
+		case <-done:
+			cancel()
+			return
+		}
+
+		select {
+		case sig := <-signals:
+			gg.log(log.WithField(ctx, "signal", sig.String()), slog.LevelError, LogLineGroupForceExit)
+			gg.exit(gg.forceExitCode)
+		case <-done:
+		}
+	}()
+
+	return ctx
+}
+
+// watchReload calls the reload func on each reload signal, until the
+// context is done or Wait returns.
+func (gg *Group) watchReload(ctx context.Context) {
+	signals := gg.reloadSignals
+	if signals == nil {
+		signals = make(chan os.Signal, 1)
+		signal.Notify(signals, gg.reloadSignal)
+	}
+
+	done := make(chan struct{})
+	gg.stopSignals = append(gg.stopSignals, func() {
+		signal.Stop(signals)
+		close(done)
+	})
+
+	go func() {
+		for {
+			select {
+			case sig := <-signals:
+				sigCtx := log.WithField(ctx, "signal", sig.String())
+				gg.log(sigCtx, slog.LevelInfo, LogLineGroupReloading)
+				if err := gg.reload(ctx); err != nil {
+					gg.log(log.WithError(sigCtx, err), slog.LevelError, LogLineGroupReloadFailed)
+				}
+			case <-ctx.Done():
+				return
+			case <-done:
+				return
+			}
+		}
+	}()
+}
+
 // Start starts the runners in the group in the background.
 // Errors are not returned until Wait is called
 // Runners are tied to the passed in context
+// Runners with dependencies are held until their dependencies are ready, an
+// unknown dependency or a dependency cycle is returned as an error.
 func (gg *Group) Start(ctx context.Context) error {
 	if gg.name != "" {
 		ctx = log.WithField(ctx, "runGroup", gg.name)
 	}
 
-	if len(gg.cancelOnSignals) > 0 {
-		ctx, _ = signal.NotifyContext(ctx, gg.cancelOnSignals...)
-	}
-
 	// Hold the lock until we have
 	// - Created all pending runners
 	// - Marked as running
@@ -144,9 +627,27 @@ func (gg *Group) Start(ctx context.Context) error {
 	if gg.running {
 		return fmt.Errorf("group already triggered")
 	}
+	if err := gg.checkDependencies(); err != nil {
+		return err
+	}
+	if len(gg.cancelOnSignals) > 0 {
+		ctx = gg.watchSignals(ctx)
+	}
+	if gg.deadline > 0 {
+		ctx, gg.cancelDeadline = context.WithTimeoutCause(ctx, gg.deadline, ErrGroupDeadline)
+		gg.deadlineContext = ctx
+	}
 	gg.running = true
 	gg.errGroup, ctx = errgroup.WithContext(ctx)
 	gg.runContext = ctx
+	if gg.reload != nil {
+		gg.watchReload(ctx)
+	}
+	if gg.done == nil {
+		gg.done = make(chan struct{})
+	}
+	go gg.watchDone(ctx)
+	go gg.stopStages(ctx)
 
 	// Forces at least one worker to keep the group open, until 'Wait' is
 	// called, allowing runners to be added after the group has started.
@@ -156,15 +657,119 @@ func (gg *Group) Start(ctx context.Context) error {
 		return nil
 	})
 
+	// Sent before the runners start, so it always comes first
+	gg.emit(RunnerEvent{Type: EventGroupStarted})
+
 	for _, rr := range gg.runners {
 		rr := rr
 		gg.startRunner(ctx, rr)
 	}
 
-	gg.logger.Info(ctx, LogLineGroupStarted)
+	gg.log(ctx, slog.LevelInfo, LogLineGroupStarted)
 	return nil
 }
 
+// Done returns a channel which is closed when the group has finished: either
+// the group's context was canceled, by an error, signal or the parent context,
+// and every runner has stopped, or Wait has returned. While the context is
+// live, the group stays open for new runners until Wait is called, so a group
+// whose runners all exit cleanly is only done once Wait returns.
+// The channel does not carry the error, call Wait for that.
+func (gg *Group) Done() <-chan struct{} {
+	gg.controlMutex.Lock()
+	defer gg.controlMutex.Unlock()
+	if gg.done == nil {
+		gg.done = make(chan struct{})
+	}
+	return gg.done
+}
+
+func (gg *Group) markDone() {
+	gg.controlMutex.Lock()
+	if gg.done == nil {
+		gg.done = make(chan struct{})
+	}
+	done := gg.done
+	gg.controlMutex.Unlock()
+
+	gg.doneOnce.Do(func() {
+		close(done)
+	})
+}
+
+// watchDone marks the group as done once the context is canceled and all
+// runners have stopped. Runners added while waiting are started with the
+// canceled context, so stop straight away, and are waited for too.
+func (gg *Group) watchDone(ctx context.Context) {
+	<-ctx.Done()
+	waited := 0
+	for {
+		gg.controlMutex.Lock()
+		runners := gg.runners[waited:]
+		waited = len(gg.runners)
+		gg.controlMutex.Unlock()
+		if len(runners) == 0 {
+			break
+		}
+		for _, rr := range runners {
+			<-rr.stopped
+		}
+	}
+	gg.markDone()
+}
+
+// Ready returns true when the group is running and every runner has signaled
+// readiness. Runners added with Add are ready as soon as they start.
+func (gg *Group) Ready() bool {
+	gg.controlMutex.Lock()
+	defer gg.controlMutex.Unlock()
+	if !gg.running {
+		return false
+	}
+	for _, rr := range gg.runners {
+		select {
+		case <-rr.ready:
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// WaitReady blocks until every runner in the group has signaled readiness,
+// returning an error if the context is done first, or if a runner exits
+// without becoming ready.
+func (gg *Group) WaitReady(ctx context.Context) error {
+	gg.controlMutex.Lock()
+	runners := make([]*runner, len(gg.runners))
+	copy(runners, gg.runners)
+	gg.controlMutex.Unlock()
+
+	return waitForReady(ctx, runners)
+}
+
+// RunnerNames returns the names of the runners which have been added and have
+// not yet stopped, in the order they were added. This includes runners which
+// are waiting for the group to start or for their dependencies.
+func (gg *Group) RunnerNames() []string {
+	gg.controlMutex.Lock()
+	defer gg.controlMutex.Unlock()
+	names := make([]string, 0, len(gg.runners))
+	for _, rr := range gg.runners {
+		select {
+		case <-rr.stopped:
+		default:
+			names = append(names, rr.name)
+		}
+	}
+	return names
+}
+
+// RunningCount returns the number of runners listed by RunnerNames.
+func (gg *Group) RunningCount() int {
+	return len(gg.RunnerNames())
+}
+
 // Run runs the runners in the group until all have exited.
 // If any function returns an error, the context passed to each is canceled.
 // Once a group is triggered with Run, no more functions can be added
@@ -177,42 +782,69 @@ func (gg *Group) Run(ctx context.Context) error {
 }
 
 // Wait waits for all runners to exit. If any runner returns an error, the first
-// error is returned.
+// error is returned. A runner returning ErrStopGroup is not an error.
 // Once Wait is called, no more runners can be added to the group
 func (gg *Group) Wait() error {
 	gg.controlMutex.Lock()
-	defer gg.controlMutex.Unlock()
 	if gg.isWaiting {
+		gg.controlMutex.Unlock()
 		return fmt.Errorf("group is already waiting")
 	}
 
 	gg.isWaiting = true
 	close(gg.holdOpen)
 
+	// The runner list is fixed once waiting, release the lock so that the
+	// group can still be inspected while the runners are running.
+	gg.controlMutex.Unlock()
+
+	watched := make(chan struct{})
 	go func() {
+		defer close(watched)
 		<-gg.runContext.Done()
-		waiting := sync.Map{}
 
+		// Each time a runner stops, list those which are still running
 		for _, rr := range gg.runners {
-			waiting.Store(rr.name, struct{}{})
 			<-rr.stopped
-			waiting.Delete(rr)
-			waiting.Range(func(key, value interface{}) bool {
-				rr := key.(string)
-				gg.logger.Debug(gg.runContext, "Waiting for runner "+rr)
-				return true
-			})
-
+			for _, other := range gg.runners {
+				select {
+				case <-other.stopped:
+				default:
+					gg.logger.Debug(gg.runContext, "Waiting for runner "+other.name)
+				}
+			}
 		}
 		gg.logger.Info(gg.runContext, "All runners exited")
 	}()
 
 	firstError := gg.errGroup.Wait()
+	// Every runner has stopped, so the watcher is finishing, nothing is
+	// logged after Wait returns
+	<-watched
+	for _, stop := range gg.stopSignals {
+		stop()
+	}
+	gg.markDone()
+	if errors.Is(firstError, ErrStopGroup) {
+		firstError = nil
+	}
+	if gg.deadlineContext != nil {
+		// Runners stopped by the deadline return nil or the context error,
+		// any other error came first. The cause is only ErrGroupDeadline when
+		// this deadline fired, not a parent context's or a runner's timeout.
+		deadlineCause := context.Cause(gg.deadlineContext)
+		gg.cancelDeadline()
+		if deadlineCause == ErrGroupDeadline && (firstError == nil || errors.Is(firstError, context.DeadlineExceeded)) {
+			firstError = fmt.Errorf("%w after %s: %w", ErrGroupDeadline, gg.deadline, context.DeadlineExceeded)
+		}
+	}
 	if firstError != nil {
-		gg.logger.Error(gg.runContext, LogLineGroupExitedWithError)
+		gg.log(gg.runContext, slog.LevelError, LogLineGroupExitedWithError)
 	} else {
-		gg.logger.Info(gg.runContext, LogLineGroupExited)
+		gg.log(gg.runContext, slog.LevelInfo, LogLineGroupExited)
 	}
+	gg.emit(RunnerEvent{Type: EventGroupExited, Err: firstError})
+	gg.closeEvents()
 
 	return firstError
 }
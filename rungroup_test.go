@@ -4,7 +4,12 @@ import (
 	"context"
 	"errors"
 	"log/slog"
+	"os"
+	"strings"
+	"sync"
+	"syscall"
 	"testing"
+	"time"
 
 	"github.com/pentops/log.go/log"
 )
@@ -50,12 +55,32 @@ func assertEntries(t testing.TB, got []logEntry, want map[string][]logEntry) {
 
 }
 
-func TestHappyPath(t *testing.T) {
-	entries := []logEntry{}
+// logCollector records the entries logged by a group. Runners log from their
+// own goroutines, so the entries are guarded by a mutex.
+type logCollector struct {
+	lock   sync.Mutex
+	logged []logEntry
+}
+
+func newLogCollector() (*logCollector, *log.CallbackLogger) {
+	lc := &logCollector{}
 	logger := log.NewCallbackLogger(func(level, message string, fields map[string]interface{}) {
-		t.Log(level, message, fields)
-		entries = append(entries, logEntry{level, message, fields})
+		lc.lock.Lock()
+		defer lc.lock.Unlock()
+		lc.logged = append(lc.logged, logEntry{level, message, fields})
 	})
+	return lc, logger
+}
+
+// entries returns a copy of the entries logged so far
+func (lc *logCollector) entries() []logEntry {
+	lc.lock.Lock()
+	defer lc.lock.Unlock()
+	return append([]logEntry(nil), lc.logged...)
+}
+
+func TestHappyPath(t *testing.T) {
+	logs, logger := newLogCollector()
 
 	// Create a new group
 	g := NewGroup(WithLogger(logger))
@@ -77,7 +102,7 @@ func TestHappyPath(t *testing.T) {
 		t.Errorf("Expected no error, got %v", err)
 	}
 
-	assertEntries(t, entries, map[string][]logEntry{
+	assertEntries(t, logs.entries(), map[string][]logEntry{
 		"t1": {
 			{level: "INFO", message: LogLineRunnerStarted},
 			{level: "INFO", message: LogLineRunnerExited},
@@ -88,6 +113,7 @@ func TestHappyPath(t *testing.T) {
 		},
 		"root": {
 			{level: "INFO", message: LogLineGroupStarted},
+			{level: "INFO", message: "All runners exited"},
 			{level: "INFO", message: LogLineGroupExited},
 		},
 	})
@@ -95,11 +121,7 @@ func TestHappyPath(t *testing.T) {
 
 func TestContextCancelOnErrors(t *testing.T) {
 
-	entries := []logEntry{}
-	logger := log.NewCallbackLogger(func(level, message string, fields map[string]interface{}) {
-		t.Log(level, message, fields)
-		entries = append(entries, logEntry{level, message, fields})
-	})
+	logs, logger := newLogCollector()
 	logger.SetLevel(slog.LevelDebug)
 
 	// Create a new group
@@ -123,7 +145,7 @@ func TestContextCancelOnErrors(t *testing.T) {
 		t.Errorf("Expected exit error, got %v", err)
 	}
 
-	assertEntries(t, entries, map[string][]logEntry{
+	assertEntries(t, logs.entries(), map[string][]logEntry{
 		"t1": {
 			{level: "INFO", message: LogLineRunnerStarted},
 			{level: "DEBUG", message: LogLineRunnerExitedWithContextCanceledError},
@@ -134,6 +156,7 @@ func TestContextCancelOnErrors(t *testing.T) {
 		},
 		"root": {
 			{level: "INFO", message: LogLineGroupStarted},
+			{level: "INFO", message: "All runners exited"},
 			{level: "ERROR", message: LogLineGroupExitedWithError},
 		},
 	})
@@ -142,11 +165,7 @@ func TestContextCancelOnErrors(t *testing.T) {
 
 func TestMultipleErrors(t *testing.T) {
 
-	entries := []logEntry{}
-	logger := log.NewCallbackLogger(func(level, message string, fields map[string]interface{}) {
-		t.Log(level, message, fields)
-		entries = append(entries, logEntry{level, message, fields})
-	})
+	logs, logger := newLogCollector()
 
 	// Create a new group
 	g := NewGroup(WithLogger(logger))
@@ -171,7 +190,7 @@ func TestMultipleErrors(t *testing.T) {
 		t.Errorf("Expected error, got nil")
 	}
 
-	assertEntries(t, entries, map[string][]logEntry{
+	assertEntries(t, logs.entries(), map[string][]logEntry{
 		"t1": {
 			{level: "INFO", message: LogLineRunnerStarted},
 			{level: "ERROR", message: LogLineRunnerExitedWithError},
@@ -182,8 +201,731 @@ func TestMultipleErrors(t *testing.T) {
 		},
 		"root": {
 			{level: "INFO", message: LogLineGroupStarted},
+			{level: "INFO", message: "All runners exited"},
 			{level: "ERROR", message: LogLineGroupExitedWithError},
 		},
 	})
 
 }
+
+func TestDependencies(t *testing.T) {
+
+	g := NewGroup()
+
+	migrated := false
+	g.AddReady("migrate", func(ctx context.Context, ready func()) error {
+		migrated = true
+		ready()
+		return nil
+	})
+
+	g.Add("server", func(ctx context.Context) error {
+		if !migrated {
+			return errors.New("server started before migrate was ready")
+		}
+		return nil
+	}, DependsOn("migrate"))
+
+	err := g.Run(context.Background())
+	if err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+}
+
+func TestDependencyNotReady(t *testing.T) {
+
+	g := NewGroup()
+
+	g.AddReady("migrate", func(ctx context.Context, ready func()) error {
+		return nil
+	})
+
+	g.Add("server", func(ctx context.Context) error {
+		t.Error("server should not start")
+		return nil
+	}, DependsOn("migrate"))
+
+	err := g.Run(context.Background())
+	if err == nil {
+		t.Errorf("Expected error, got nil")
+	}
+}
+
+func TestDependencyCycle(t *testing.T) {
+
+	g := NewGroup()
+
+	g.Add("a", func(ctx context.Context) error {
+		return nil
+	}, DependsOn("b"))
+
+	g.Add("b", func(ctx context.Context) error {
+		return nil
+	}, DependsOn("a"))
+
+	err := g.Start(context.Background())
+	if err == nil {
+		t.Fatalf("Expected error, got nil")
+	}
+	if err.Error() != "runner dependency cycle: a -> b -> a" {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
+
+func TestReady(t *testing.T) {
+
+	g := NewGroup()
+
+	signalReady := make(chan struct{})
+	g.AddReady("slow", func(ctx context.Context, ready func()) error {
+		<-signalReady
+		ready()
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	g.Add("fast", func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	if g.Ready() {
+		t.Errorf("Expected not ready before start")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	if err := g.Start(ctx); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if g.Ready() {
+		t.Errorf("Expected not ready before slow runner is ready")
+	}
+
+	close(signalReady)
+	if err := g.WaitReady(context.Background()); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+
+	if !g.Ready() {
+		t.Errorf("Expected ready")
+	}
+
+	cancel()
+	if err := g.Wait(); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+}
+
+func TestStopGroup(t *testing.T) {
+
+	logs, logger := newLogCollector()
+	logger.SetLevel(slog.LevelDebug)
+
+	g := NewGroup(WithLogger(logger))
+
+	sidecarStopped := false
+	g.Add("sidecar", func(ctx context.Context) error {
+		<-ctx.Done()
+		sidecarStopped = true
+		return ctx.Err()
+	})
+
+	g.Add("main", func(ctx context.Context) error {
+		return ErrStopGroup
+	})
+
+	err := g.Run(context.Background())
+	if err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+	if !sidecarStopped {
+		t.Errorf("Expected sidecar to be stopped")
+	}
+
+	assertEntries(t, logs.entries(), map[string][]logEntry{
+		"sidecar": {
+			{level: "INFO", message: LogLineRunnerStarted},
+			{level: "DEBUG", message: LogLineRunnerExitedWithContextCanceledError},
+		},
+		"main": {
+			{level: "INFO", message: LogLineRunnerStarted},
+			{level: "INFO", message: LogLineRunnerStoppedGroup},
+		},
+	})
+}
+
+func TestRunnerNames(t *testing.T) {
+
+	g := NewGroup()
+
+	g.Add("quick", func(ctx context.Context) error {
+		return nil
+	})
+
+	g.Add("watcher", func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	if got := g.RunnerNames(); len(got) != 2 {
+		t.Errorf("Expected both runners before start, got %v", got)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if err := g.Start(ctx); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// quick exits straight away
+	deadline := time.Now().Add(time.Second)
+	for g.RunningCount() != 1 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+
+	if got := g.RunnerNames(); len(got) != 1 || got[0] != "watcher" {
+		t.Errorf("Expected only watcher to be running, got %v", got)
+	}
+
+	cancel()
+	if err := g.Wait(); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+	if got := g.RunningCount(); got != 0 {
+		t.Errorf("Expected no running runners after Wait, got %d", got)
+	}
+}
+
+func TestRunnerTimeout(t *testing.T) {
+
+	g := NewGroup()
+
+	g.Add("slow", func(ctx context.Context) error {
+		<-ctx.Done()
+		// ignore cancellation for a bit before returning
+		time.Sleep(10 * time.Millisecond)
+		return ctx.Err()
+	}, WithTimeout(10*time.Millisecond))
+
+	g.Add("other", func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	err := g.Run(context.Background())
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Expected deadline exceeded, got %v", err)
+	}
+	if err.Error() != `runner "slow" timed out after 10ms: context deadline exceeded` {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
+
+func TestSecondSignalForceExits(t *testing.T) {
+
+	g := NewGroup(WithCancelOnSignals(), WithForceExitCode(3))
+	signals := make(chan os.Signal, 2)
+	g.signals = signals
+	exitCode := make(chan int, 1)
+	g.exit = func(code int) {
+		exitCode <- code
+	}
+
+	canceled := make(chan struct{})
+	release := make(chan struct{})
+	g.Add("hung", func(ctx context.Context) error {
+		<-ctx.Done()
+		close(canceled)
+		// shutdown hangs until the test releases it
+		<-release
+		return ctx.Err()
+	})
+
+	if err := g.Start(context.Background()); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	signals <- syscall.SIGTERM
+	select {
+	case <-canceled:
+	case <-time.After(time.Second):
+		t.Fatal("Expected first signal to cancel the runner")
+	}
+
+	signals <- syscall.SIGTERM
+	select {
+	case code := <-exitCode:
+		if code != 3 {
+			t.Errorf("Expected exit code 3, got %d", code)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected second signal to force exit")
+	}
+
+	close(release)
+	if err := g.Wait(); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+}
+
+func TestCustomLogLines(t *testing.T) {
+
+	logs, logger := newLogCollector()
+	logger.SetLevel(slog.LevelDebug)
+
+	g := NewGroup(
+		WithLogger(logger),
+		WithLogMessage(LogLineGroupStarted, "Server starting"),
+		WithLogLevel(LogLineGroupExited, slog.LevelDebug),
+		WithLogMessage(LogLineRunnerExited, "Worker done"),
+		WithLogLevel(LogLineRunnerExited, slog.LevelWarn),
+	)
+
+	g.Add("t1", func(ctx context.Context) error {
+		return nil
+	})
+
+	if err := g.Run(context.Background()); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+
+	assertEntries(t, logs.entries(), map[string][]logEntry{
+		"t1": {
+			{level: "INFO", message: LogLineRunnerStarted},
+			{level: "WARN", message: "Worker done"},
+		},
+	})
+
+	// The root also logs when all runners exit, in the background
+	found := map[string]bool{}
+	for _, entry := range logs.entries() {
+		found[entry.level+" "+entry.message] = true
+	}
+	for _, want := range []logEntry{
+		{level: "INFO", message: "Server starting"},
+		{level: "DEBUG", message: LogLineGroupExited},
+	} {
+		if !found[want.level+" "+want.message] {
+			t.Errorf("Expected %s log %q", want.level, want.message)
+		}
+	}
+}
+
+func TestRunnerFields(t *testing.T) {
+
+	logs, logger := newLogCollector()
+
+	g := NewGroup(WithLogger(logger))
+
+	g.Add("acme", func(ctx context.Context) error {
+		return nil
+	}, WithFields(map[string]interface{}{"tenant": "acme"}))
+
+	g.Add("plain", func(ctx context.Context) error {
+		return nil
+	})
+
+	if err := g.Run(context.Background()); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+
+	assertEntries(t, logs.entries(), map[string][]logEntry{
+		"acme": {
+			{level: "INFO", message: LogLineRunnerStarted},
+			{level: "INFO", message: LogLineRunnerExited},
+		},
+	})
+
+	for _, entry := range logs.entries() {
+		tenant, ok := entry.fields["tenant"]
+		switch entry.fields["runner"] {
+		case "acme":
+			if tenant != "acme" {
+				t.Errorf("Expected tenant field on %q, got %v", entry.message, entry.fields)
+			}
+		default:
+			if ok {
+				t.Errorf("Unexpected tenant field on %q, got %v", entry.message, entry.fields)
+			}
+		}
+	}
+}
+
+func TestDone(t *testing.T) {
+
+	t.Run("canceled", func(t *testing.T) {
+		g := NewGroup()
+		g.Add("worker", func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		})
+
+		ctx, cancel := context.WithCancel(context.Background())
+		if err := g.Start(ctx); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		select {
+		case <-g.Done():
+			t.Fatal("Expected group to be running")
+		default:
+		}
+
+		cancel()
+		select {
+		case <-g.Done():
+		case <-time.After(time.Second):
+			t.Fatal("Expected group to be done after cancel")
+		}
+
+		if err := g.Wait(); err != nil {
+			t.Errorf("Expected no error, got %v", err)
+		}
+	})
+
+	t.Run("error", func(t *testing.T) {
+		g := NewGroup()
+		g.Add("failing", func(ctx context.Context) error {
+			return errors.New("failed")
+		})
+		if err := g.Start(context.Background()); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		select {
+		case <-g.Done():
+		case <-time.After(time.Second):
+			t.Fatal("Expected group to be done after an error")
+		}
+
+		if err := g.Wait(); err == nil {
+			t.Errorf("Expected error from Wait")
+		}
+	})
+
+	t.Run("wait", func(t *testing.T) {
+		g := NewGroup()
+		g.Add("quick", func(ctx context.Context) error {
+			return nil
+		})
+		done := g.Done()
+		if err := g.Run(context.Background()); err != nil {
+			t.Errorf("Expected no error, got %v", err)
+		}
+		select {
+		case <-done:
+		default:
+			t.Fatal("Expected group to be done after Wait")
+		}
+	})
+}
+
+func TestGracePeriod(t *testing.T) {
+
+	t.Run("within grace", func(t *testing.T) {
+		logs, logger := newLogCollector()
+		g := NewGroup(WithLogger(logger))
+		g.Add("flaky", func(ctx context.Context) error {
+			return errors.New("connection refused")
+		}, WithGracePeriod(time.Minute))
+
+		if err := g.Run(context.Background()); err != nil {
+			t.Errorf("Expected no error, got %v", err)
+		}
+		assertEntries(t, logs.entries(), map[string][]logEntry{
+			"flaky": {
+				{level: "INFO", message: LogLineRunnerStarted},
+				{level: "WARN", message: LogLineRunnerErrorInGracePeriod},
+			},
+		})
+	})
+
+	t.Run("after grace", func(t *testing.T) {
+		logs, logger := newLogCollector()
+		g := NewGroup(WithLogger(logger))
+		failure := errors.New("failed")
+		g.Add("flaky", func(ctx context.Context) error {
+			time.Sleep(10 * time.Millisecond)
+			return failure
+		}, WithGracePeriod(time.Millisecond))
+
+		if err := g.Run(context.Background()); !errors.Is(err, failure) {
+			t.Errorf("Expected failure, got %v", err)
+		}
+		assertEntries(t, logs.entries(), map[string][]logEntry{
+			"flaky": {
+				{level: "INFO", message: LogLineRunnerStarted},
+				{level: "ERROR", message: LogLineRunnerExitedWithError},
+			},
+		})
+	})
+}
+
+func TestReloadSignal(t *testing.T) {
+
+	reloads := make(chan struct{}, 2)
+	reloadCount := 0
+	g := NewGroup(WithReloadSignal(syscall.SIGHUP, func(ctx context.Context) error {
+		reloadCount++
+		reloads <- struct{}{}
+		if reloadCount == 1 {
+			return errors.New("bad cert")
+		}
+		return nil
+	}))
+	signals := make(chan os.Signal, 2)
+	g.reloadSignals = signals
+
+	stop := make(chan struct{})
+	g.Add("server", func(ctx context.Context) error {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-stop:
+			return nil
+		}
+	})
+
+	if err := g.Start(context.Background()); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	for i := 0; i < 2; i++ {
+		signals <- syscall.SIGHUP
+		select {
+		case <-reloads:
+		case <-time.After(time.Second):
+			t.Fatalf("Expected reload %d to be called", i+1)
+		}
+	}
+
+	if names := g.RunnerNames(); len(names) != 1 {
+		t.Errorf("Expected the runner to keep running after a failed reload, got %v", names)
+	}
+
+	close(stop)
+	if err := g.Wait(); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+}
+
+func TestEvents(t *testing.T) {
+
+	g := NewGroup()
+	events := g.Events()
+
+	g.AddReady("server", func(ctx context.Context, ready func()) error {
+		ready()
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	g.Add("job", func(ctx context.Context) error {
+		return ErrStopGroup
+	}, DependsOn("server"))
+
+	if err := g.Run(context.Background()); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	got := []string{}
+	for event := range events {
+		if event.Time.IsZero() {
+			t.Errorf("Expected event time to be set for %s", event.Type)
+		}
+		desc := event.Type.String()
+		if event.Runner != "" {
+			desc += " " + event.Runner
+		}
+		if event.Err != nil {
+			desc += ": " + event.Err.Error()
+		}
+		got = append(got, desc)
+	}
+
+	want := []string{
+		"GroupStarted",
+		"RunnerStarted server",
+		"RunnerStarted job",
+		"RunnerExited job: stop group",
+		"RunnerExited server: context canceled",
+		"GroupExited",
+	}
+	if len(got) != len(want) {
+		t.Fatalf("Expected events %q, got %q", want, got)
+	}
+	for idx := range want {
+		if got[idx] != want[idx] {
+			t.Errorf("Event %d: expected %q, got %q", idx, want[idx], got[idx])
+		}
+	}
+}
+
+func TestGroupDeadline(t *testing.T) {
+
+	g := NewGroup(WithDeadline(20 * time.Millisecond))
+	g.Add("clean", func(ctx context.Context) error {
+		<-ctx.Done()
+		return nil
+	})
+	g.Add("ctxErr", func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	err := g.Run(context.Background())
+	if !errors.Is(err, ErrGroupDeadline) {
+		t.Fatalf("Expected ErrGroupDeadline, got %v", err)
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected the error to wrap context.DeadlineExceeded")
+	}
+	if err.Error() != "group deadline exceeded after 20ms: context deadline exceeded" {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	t.Run("runner error first", func(t *testing.T) {
+		failErr := errors.New("failed")
+		g := NewGroup(WithDeadline(time.Second))
+		g.Add("fails", func(ctx context.Context) error {
+			return failErr
+		})
+
+		err := g.Run(context.Background())
+		if err != failErr {
+			t.Errorf("Expected runner error, got %v", err)
+		}
+	})
+
+	t.Run("parent deadline first", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+
+		g := NewGroup(WithDeadline(time.Second))
+		g.Add("ctxErr", func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		})
+
+		err := g.Run(ctx)
+		if errors.Is(err, ErrGroupDeadline) {
+			t.Errorf("Expected the parent deadline not to be ErrGroupDeadline, got %v", err)
+		}
+	})
+}
+
+func TestWaitingForRunnersLog(t *testing.T) {
+
+	waiting := make(chan string, 10)
+	allExited := make(chan struct{})
+	logger := log.NewCallbackLogger(func(level, message string, fields map[string]interface{}) {
+		if name, ok := strings.CutPrefix(message, "Waiting for runner "); ok {
+			waiting <- name
+		}
+		if message == "All runners exited" {
+			close(allExited)
+		}
+	})
+	logger.SetLevel(slog.LevelDebug)
+
+	g := NewGroup(WithLogger(logger))
+	release := map[string]chan struct{}{}
+	for _, name := range []string{"a", "b", "c"} {
+		release[name] = make(chan struct{})
+		g.Add(name, func(ctx context.Context) error {
+			<-ctx.Done()
+			<-release[name]
+			return nil
+		})
+	}
+	g.Add("stop", func(ctx context.Context) error {
+		return ErrStopGroup
+	})
+
+	if err := g.Start(context.Background()); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	result := make(chan error)
+	go func() {
+		result <- g.Wait()
+	}()
+
+	// Stop c before a, so that once a stops only b is still running
+	close(release["c"])
+	<-g.runners[2].stopped
+	close(release["a"])
+
+	select {
+	case name := <-waiting:
+		if name != "b" {
+			t.Errorf("Expected to wait for b, got %q", name)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected a waiting log once a stopped")
+	}
+	close(release["b"])
+
+	if err := <-result; err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	select {
+	case <-allExited:
+	case <-time.After(time.Second):
+		t.Fatal("Expected all runners exited log")
+	}
+
+	close(waiting)
+	for name := range waiting {
+		t.Errorf("Expected no more waiting logs, got %q", name)
+	}
+}
+
+func TestShutdownPriority(t *testing.T) {
+	g := NewGroup()
+	events := make(chan string, 10)
+
+	g.Add("db", func(ctx context.Context) error {
+		<-ctx.Done()
+		events <- "db canceled"
+		return nil
+	})
+
+	g.Add("cache", func(ctx context.Context) error {
+		<-ctx.Done()
+		events <- "cache canceled"
+		return nil
+	}, WithShutdownPriority(-5))
+
+	g.Add("server", func(ctx context.Context) error {
+		<-ctx.Done()
+		events <- "server canceled"
+		// Draining takes a while, the db must stay up throughout
+		time.Sleep(10 * time.Millisecond)
+		events <- "server exited"
+		return ctx.Err()
+	}, WithShutdownPriority(10))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	if err := g.Start(ctx); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if err := g.WaitReady(ctx); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	cancel()
+
+	if err := g.Wait(); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	close(events)
+
+	got := []string{}
+	for event := range events {
+		got = append(got, event)
+	}
+	want := []string{"server canceled", "server exited", "db canceled", "cache canceled"}
+	if strings.Join(got, ", ") != strings.Join(want, ", ") {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+}